@@ -29,6 +29,8 @@ const (
 	DJN
 	SPL
 	NOP
+	LDP
+	STP
 )
 
 func (o OpCode) String() string {
@@ -67,11 +69,33 @@ func (o OpCode) String() string {
 		return "SPL"
 	case NOP:
 		return "NOP"
+	case LDP:
+		return "LDP"
+	case STP:
+		return "STP"
 	default:
 		return "???"
 	}
 }
 
+// MarshalText encodes the opcode as its name
+func (o OpCode) MarshalText() ([]byte, error) {
+	if o > STP {
+		return nil, fmt.Errorf("invalid opcode: %d", o)
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText decodes an opcode from its name
+func (o *OpCode) UnmarshalText(text []byte) error {
+	op, err := getOpCode(string(text))
+	if err != nil {
+		return err
+	}
+	*o = op
+	return nil
+}
+
 func getOpCode(op string) (OpCode, error) {
 	switch strings.ToLower(op) {
 	case "dat":
@@ -108,6 +132,10 @@ func getOpCode(op string) (OpCode, error) {
 		return SPL, nil
 	case "nop":
 		return NOP, nil
+	case "ldp":
+		return LDP, nil
+	case "stp":
+		return STP, nil
 	default:
 		return 0, fmt.Errorf("invalid opcode '%s'", op)
 	}
@@ -145,6 +173,24 @@ func (m OpMode) String() string {
 	}
 }
 
+// MarshalText encodes the op mode as its name
+func (m OpMode) MarshalText() ([]byte, error) {
+	if m > I {
+		return nil, fmt.Errorf("invalid op mode: %d", m)
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes an op mode from its name
+func (m *OpMode) UnmarshalText(text []byte) error {
+	mode, err := getOpMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
 func getOpMode(opModeStr string) (OpMode, error) {
 	switch strings.ToLower(opModeStr) {
 	case "a":
@@ -249,6 +295,24 @@ func (m AddressMode) String() string {
 	}
 }
 
+// MarshalText encodes the address mode as its redcode symbol
+func (m AddressMode) MarshalText() ([]byte, error) {
+	if m > B_INCREMENT {
+		return nil, fmt.Errorf("invalid address mode: %d", m)
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes an address mode from its redcode symbol
+func (m *AddressMode) UnmarshalText(text []byte) error {
+	mode, err := getAddressMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
 func getAddressMode(modeStr string) (AddressMode, error) {
 	switch modeStr {
 	case "#":
@@ -311,12 +375,12 @@ func signedAddress(a, coresize Address) int {
 
 // Instruction represents the raw values of a memory address
 type Instruction struct {
-	Op     OpCode
-	OpMode OpMode
-	A      Address
-	AMode  AddressMode
-	B      Address
-	BMode  AddressMode
+	Op     OpCode      `json:"op"`
+	OpMode OpMode      `json:"op_mode"`
+	A      Address     `json:"a"`
+	AMode  AddressMode `json:"a_mode"`
+	B      Address     `json:"b"`
+	BMode  AddressMode `json:"b_mode"`
 }
 
 // String returns the decompiled instruction with unsigned field values
@@ -0,0 +1,97 @@
+package gmars
+
+import (
+	"os"
+	"testing"
+)
+
+func loadBenchWarriors(b *testing.B, config SimulatorConfig, names ...string) []WarriorData {
+	warriors := make([]WarriorData, 0, len(names))
+	for _, name := range names {
+		in, err := os.Open(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		data, err := CompileWarrior(in, config)
+		in.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+		warriors = append(warriors, data)
+	}
+	return warriors
+}
+
+// benchmarkBattle reports simulated cycles per second, building every report
+// as if a reporter were attached when baseline is set.
+func benchmarkBattle(b *testing.B, baseline bool, names ...string) {
+	config := ConfigNOP94
+	warriors := loadBenchWarriors(b, config, names...)
+
+	sim, err := newReportSim(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := range warriors {
+		_, err := sim.AddWarrior(&warriors[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	if baseline {
+		sim.reportMask = ReportAll
+		sim.foldReads = true
+		sim.foldWrites = true
+	}
+
+	placement := SeededPlacement(config, len(warriors), 1)
+	cycles := 0
+	b.ResetTimer()
+	for round := 0; round < b.N; round++ {
+		if round > 0 {
+			sim.Reset()
+		}
+		p, err := placement(round)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i, offset := range p.Offsets {
+			err := sim.SpawnWarrior(i, offset)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		// Run would recompute the report mask, so cycles are run directly
+		for sim.RunCycle() > 1 {
+		}
+		cycles += sim.CycleCount()
+	}
+	b.ReportMetric(float64(cycles)/b.Elapsed().Seconds(), "cycles/s")
+}
+
+func BenchmarkBattle(b *testing.B) {
+	benchmarkBattle(b, false, "warriors/94/simpleshot.red", "warriors/94/bombspiral.red")
+}
+
+func BenchmarkBattleBaseline(b *testing.B) {
+	benchmarkBattle(b, true, "warriors/94/simpleshot.red", "warriors/94/bombspiral.red")
+}
+
+func BenchmarkBattlePaper(b *testing.B) {
+	benchmarkBattle(b, false, "warriors/94/paperhaze.red", "warriors/94/scaryvampire.red")
+}
+
+func BenchmarkBattlePaperBaseline(b *testing.B) {
+	benchmarkBattle(b, true, "warriors/94/paperhaze.red", "warriors/94/scaryvampire.red")
+}
+
+func BenchmarkImps(b *testing.B) {
+	benchmarkBattle(b, false, "warriors/94/imp.red", "warriors/94/imp.red")
+}
+
+func BenchmarkCompile(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		loadBenchWarriors(b, ConfigNOP94, "warriors/94/paperhaze.red", "warriors/94/bombspiral.red", "test_files/nestedfor.red")
+	}
+}
@@ -0,0 +1,151 @@
+package gmars
+
+import "fmt"
+
+type BreakType uint8
+
+const (
+	// BreakExec stops before a warrior executes an instruction in the
+	// address range
+	BreakExec BreakType = iota
+	// BreakWrite stops after a warrior writes, increments or decrements an
+	// address in the address range
+	BreakWrite
+	// BreakProcesses stops after a warrior's process count crosses the
+	// threshold in either direction
+	BreakProcesses
+	// BreakCycle stops at the start of a cycle
+	BreakCycle
+)
+
+// Breakpoint defines a condition that stops Simulator.Run early.
+//
+// Start and End define an inclusive range of addresses for BreakExec and
+// BreakWrite, which wraps around the end of core if End is less than Start.
+// Warrior restricts the breakpoint to a single warrior, or any warrior if it
+// is -1. Warrior is ignored for BreakCycle.
+type Breakpoint struct {
+	Type      BreakType
+	Warrior   int
+	Start     Address
+	End       Address
+	Threshold Address
+	Cycle     int
+}
+
+// BreakEvent describes the Breakpoint that stopped Simulator.Run. Address is
+// the address executed or written, and for BreakProcesses it is the new
+// process count of the warrior.
+type BreakEvent struct {
+	ID           int
+	Type         BreakType
+	Cycle        int
+	WarriorIndex int
+	Address      Address
+}
+
+// breakpoint is a Breakpoint registered with a simulator
+type breakpoint struct {
+	id int
+	Breakpoint
+}
+
+func (s *reportSim) AddBreakpoint(b Breakpoint) (int, error) {
+	if b.Type > BreakCycle {
+		return 0, fmt.Errorf("invalid breakpoint type")
+	}
+	if b.Warrior < -1 {
+		return 0, fmt.Errorf("invalid warrior index %d", b.Warrior)
+	}
+	if b.Start >= s.m || b.End >= s.m {
+		return 0, fmt.Errorf("breakpoint address out of range")
+	}
+	if b.Cycle < 0 {
+		return 0, fmt.Errorf("invalid cycle %d", b.Cycle)
+	}
+
+	id := s.nextBreakID
+	s.nextBreakID++
+	s.breakpoints = append(s.breakpoints, breakpoint{id: id, Breakpoint: b})
+	return id, nil
+}
+
+func (s *reportSim) RemoveBreakpoint(id int) {
+	for i, b := range s.breakpoints {
+		if b.id == id {
+			s.breakpoints = append(s.breakpoints[:i], s.breakpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *reportSim) ClearBreakpoints() {
+	s.breakpoints = nil
+}
+
+// inRange returns true if a is within the breakpoint's address range
+func (b *breakpoint) inRange(a, coresize Address) bool {
+	return (a+coresize-b.Start)%coresize <= (b.End+coresize-b.Start)%coresize
+}
+
+func (b *breakpoint) matchWarrior(wi int) bool {
+	return b.Warrior == -1 || b.Warrior == wi
+}
+
+// setBreak stores the event for b unless an earlier breakpoint has already
+// fired
+func (s *reportSim) setBreak(b *breakpoint, wi int, a Address) {
+	if s.breakEvent != nil {
+		return
+	}
+	s.breakEvent = &BreakEvent{
+		ID:           b.id,
+		Type:         b.Type,
+		Cycle:        int(s.cycleCount),
+		WarriorIndex: wi,
+		Address:      a,
+	}
+}
+
+func (s *reportSim) checkCycleBreak() {
+	for i := range s.breakpoints {
+		b := &s.breakpoints[i]
+		if b.Type == BreakCycle && b.Cycle == int(s.cycleCount) {
+			s.setBreak(b, -1, 0)
+		}
+	}
+}
+
+func (s *reportSim) checkExecBreak(wi int, pc Address) {
+	for i := range s.breakpoints {
+		b := &s.breakpoints[i]
+		if b.Type == BreakExec && b.matchWarrior(wi) && b.inRange(pc, s.m) {
+			s.setBreak(b, wi, pc)
+		}
+	}
+}
+
+func (s *reportSim) checkProcessBreak(wi int, before, after Address) {
+	for i := range s.breakpoints {
+		b := &s.breakpoints[i]
+		if b.Type != BreakProcesses || !b.matchWarrior(wi) {
+			continue
+		}
+		if (before < b.Threshold && after >= b.Threshold) || (before >= b.Threshold && after < b.Threshold) {
+			s.setBreak(b, wi, after)
+		}
+	}
+}
+
+// checkWriteBreak is called from Report while Run is watching for writes
+func (s *reportSim) checkWriteBreak(report Report) {
+	if report.Type != WarriorWrite && report.Type != WarriorIncrement && report.Type != WarriorDecrement {
+		return
+	}
+	for i := range s.breakpoints {
+		b := &s.breakpoints[i]
+		if b.Type == BreakWrite && b.matchWarrior(report.WarriorIndex) && b.inRange(report.Address, s.m) {
+			s.setBreak(b, report.WarriorIndex, report.Address)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gmars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBreakpointSim(t *testing.T, code []Instruction, offsets ...Address) Simulator {
+	sim, err := NewSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	data := WarriorData{Code: code}
+	for i, offset := range offsets {
+		_, err := sim.AddWarrior(&data)
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(i, offset))
+	}
+	return sim
+}
+
+var (
+	breakImp = []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}
+	breakSpl = []Instruction{
+		{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: JMP, OpMode: B, AMode: DIRECT, A: 8000 - 1, BMode: DIRECT, B: 0},
+	}
+)
+
+func TestBreakExec(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0, 4000)
+	id, err := sim.AddBreakpoint(Breakpoint{Type: BreakExec, Warrior: 1, Start: 4010, End: 4010})
+	require.NoError(t, err)
+
+	result, event := sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, []bool{true, true}, result)
+	assert.Equal(t, BreakEvent{ID: id, Type: BreakExec, Cycle: 10, WarriorIndex: 1, Address: 4010}, *event)
+
+	// stopped between warriors, with warrior 0 done with cycle 10
+	assert.Equal(t, 10, sim.CycleCount())
+	pc, _ := sim.GetWarrior(0).NextPC()
+	assert.Equal(t, Address(11), pc)
+	pc, _ = sim.GetWarrior(1).NextPC()
+	assert.Equal(t, Address(4010), pc)
+
+	// continuing does not stop at the same breakpoint again
+	sim.RemoveBreakpoint(id)
+	_, err = sim.AddBreakpoint(Breakpoint{Type: BreakExec, Warrior: -1, Start: 7990, End: 5})
+	require.NoError(t, err)
+	_, event = sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, BreakExec, event.Type)
+	assert.Equal(t, 1, event.WarriorIndex)
+	assert.Equal(t, Address(7990), event.Address)
+	assert.Equal(t, 3990, event.Cycle)
+}
+
+func TestBreakExecResume(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0, 4000)
+	_, err := sim.AddBreakpoint(Breakpoint{Type: BreakExec, Warrior: -1, Start: 5, End: 6})
+	require.NoError(t, err)
+
+	_, event := sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, 5, event.Cycle)
+	_, event = sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, 6, event.Cycle)
+	assert.Equal(t, Address(6), event.Address)
+}
+
+func TestBreakWrite(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0, 4000)
+	id, err := sim.AddBreakpoint(Breakpoint{Type: BreakWrite, Warrior: -1, Start: 20, End: 25})
+	require.NoError(t, err)
+
+	_, event := sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, BreakEvent{ID: id, Type: BreakWrite, Cycle: 19, WarriorIndex: 0, Address: 20}, *event)
+
+	// the write has happened and warrior 1 has not run yet
+	assert.Equal(t, breakImp[0], sim.GetMem(20))
+	pc, _ := sim.GetWarrior(1).NextPC()
+	assert.Equal(t, Address(4019), pc)
+
+	_, event = sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, Address(21), event.Address)
+}
+
+func TestBreakProcesses(t *testing.T) {
+	sim := newBreakpointSim(t, breakSpl, 0)
+	id, err := sim.AddBreakpoint(Breakpoint{Type: BreakProcesses, Warrior: 0, Threshold: 5})
+	require.NoError(t, err)
+
+	_, event := sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, BreakEvent{ID: id, Type: BreakProcesses, Cycle: 5, WarriorIndex: 0, Address: 5}, *event)
+	assert.Equal(t, Address(5), sim.GetWarrior(0).ThreadCount())
+}
+
+func TestBreakCycle(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0, 4000)
+	id, err := sim.AddBreakpoint(Breakpoint{Type: BreakCycle, Cycle: 100})
+	require.NoError(t, err)
+
+	_, event := sim.Run()
+	require.NotNil(t, event)
+	assert.Equal(t, BreakEvent{ID: id, Type: BreakCycle, Cycle: 100, WarriorIndex: -1}, *event)
+	assert.Equal(t, 100, sim.CycleCount())
+	pc, _ := sim.GetWarrior(0).NextPC()
+	assert.Equal(t, Address(100), pc)
+
+	result, event := sim.Run()
+	assert.Nil(t, event)
+	assert.Equal(t, []bool{true, true}, result)
+	assert.Equal(t, sim.MaxCycles(), sim.CycleCount())
+}
+
+func TestBreakRunCycle(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0, 4000)
+	_, err := sim.AddBreakpoint(Breakpoint{Type: BreakCycle, Cycle: 0})
+	require.NoError(t, err)
+
+	// RunCycle ignores breakpoints
+	sim.RunCycle()
+	assert.Equal(t, 1, sim.CycleCount())
+
+	sim.ClearBreakpoints()
+	_, err = sim.AddBreakpoint(Breakpoint{Type: BreakCycle, Cycle: 0})
+	require.NoError(t, err)
+	sim.ClearBreakpoints()
+	_, event := sim.Run()
+	assert.Nil(t, event)
+}
+
+func TestBreakpointInvalid(t *testing.T) {
+	sim := newBreakpointSim(t, breakImp, 0)
+
+	_, err := sim.AddBreakpoint(Breakpoint{Type: BreakCycle + 1})
+	require.Error(t, err)
+	_, err = sim.AddBreakpoint(Breakpoint{Type: BreakExec, Warrior: -2})
+	require.Error(t, err)
+	_, err = sim.AddBreakpoint(Breakpoint{Type: BreakExec, Start: 8000, End: 8000})
+	require.Error(t, err)
+	_, err = sim.AddBreakpoint(Breakpoint{Type: BreakCycle, Cycle: -1})
+	require.Error(t, err)
+}
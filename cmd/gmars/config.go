@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/bobertlo/gmars"
+)
+
+// configFlags holds the flags shared between commands that are used to
+// build a SimulatorConfig
+type configFlags struct {
+	use88  *bool
+	size   *int
+	procs  *int
+	cycles *int
+	length *int
+	preset *string
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	return &configFlags{
+		use88:  fs.Bool("8", false, "Enforce ICWS'88 rules"),
+		size:   fs.Int("s", 8000, "Size of core"),
+		procs:  fs.Int("p", 8000, "Max. Processes"),
+		cycles: fs.Int("c", 80000, "Cycles until tie"),
+		length: fs.Int("l", 100, "Max. warrior length"),
+		preset: fs.String("preset", "", "Load named preset config (and ignore other flags)"),
+	}
+}
+
+// config returns the named preset config if one was specified, or a config
+// built from the other flags
+func (f *configFlags) config() (gmars.SimulatorConfig, error) {
+	if *f.preset != "" {
+		return gmars.PresetConfig(*f.preset)
+	}
+
+	var mode gmars.SimulatorMode
+	if *f.use88 {
+		mode = gmars.ICWS88
+	} else {
+		mode = gmars.ICWS94
+	}
+	coresize := gmars.Address(*f.size)
+	processes := gmars.Address(*f.procs)
+	cycles := gmars.Address(*f.cycles)
+	length := gmars.Address(*f.length)
+	return gmars.NewQuickConfig(mode, coresize, processes, cycles, length), nil
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobertlo/gmars"
+)
+
+const (
+	debuggerHelp = `Commands:
+  s, step [n]                 run n cycles (default 1)
+  c, continue                 run until a breakpoint or the end of the round
+  b, break <addr>             stop when a warrior is about to execute addr
+  b, break cycle <n>          stop at the start of cycle n
+  w, watch <addr> [end]       stop after a warrior writes to addresses addr-end
+  d, delete <addr>|cycle <n>  remove a breakpoint
+  d, delete watch <addr>      remove a watchpoint
+  d, delete all               remove all breakpoints and watchpoints
+  i, info                     show the status and breakpoints
+  l, list [addr] [n]          list n instructions of core (default 10)
+  queue [warrior]             show the process queue of each warrior
+  e, edit <addr> <inst>       replace the instruction at addr
+  h, help                     show this message
+  q, quit, exit               exit the debugger
+
+An empty line repeats the last command.
+`
+	defaultListLength = 10
+)
+
+// debugger runs a single round in an interactive command line debugger,
+// similar to the pMARS cdb. Only the chosen round is run, so warriors start
+// it with empty P-space.
+type debugger struct {
+	config      gmars.SimulatorConfig
+	sim         gmars.ReportingSimulator
+	out         io.Writer
+	breakAddrs  map[gmars.Address]int
+	breakCycles map[int]int
+	watches     map[gmars.Address]watch
+}
+
+// watch is a write watchpoint on a range of addresses
+type watch struct {
+	id  int
+	end gmars.Address
+}
+
+func runDebugger(config gmars.SimulatorConfig, warriors []gmars.WarriorData, placement gmars.PlacementFunc, round int, in io.Reader, out io.Writer) error {
+	sim, err := gmars.NewReportingSimulator(config)
+	if err != nil {
+		return err
+	}
+	for i := range warriors {
+		_, err := sim.AddWarrior(&warriors[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := placement(round)
+	if err != nil {
+		return err
+	}
+	for i, offset := range p.Offsets {
+		err := sim.SpawnWarrior(i, offset)
+		if err != nil {
+			return err
+		}
+	}
+	err = sim.SetFirstWarrior(p.First)
+	if err != nil {
+		return err
+	}
+
+	d := &debugger{
+		config:      config,
+		sim:         sim,
+		out:         out,
+		breakAddrs:  make(map[gmars.Address]int),
+		breakCycles: make(map[int]int),
+		watches:     make(map[gmars.Address]watch),
+	}
+
+	fmt.Fprintf(out, "Type 'help' for a list of commands.\n")
+	d.printStatus()
+
+	lastLine := ""
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "(gmars) ")
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = lastLine
+		}
+		lastLine = line
+
+		if d.exec(line) {
+			return nil
+		}
+	}
+	fmt.Fprintln(out)
+	return scanner.Err()
+}
+
+// exec runs a single command line and returns true if the debugger should
+// exit
+func (d *debugger) exec(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	var err error
+	switch strings.ToLower(fields[0]) {
+	case "s", "step":
+		err = d.step(fields[1:])
+	case "c", "continue":
+		d.cont()
+	case "b", "break":
+		err = d.setBreak(fields[1:], true)
+	case "w", "watch":
+		err = d.watch(fields[1:])
+	case "d", "delete":
+		err = d.setBreak(fields[1:], false)
+	case "i", "info":
+		d.printStatus()
+		d.printBreaks()
+	case "l", "list":
+		err = d.list(fields[1:])
+	case "queue":
+		err = d.queue(fields[1:])
+	case "e", "edit":
+		err = d.edit(fields[1:])
+	case "h", "help":
+		fmt.Fprint(d.out, debuggerHelp)
+	case "q", "quit", "exit":
+		return true
+	default:
+		err = fmt.Errorf("unknown command '%s'", fields[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %s\n", err)
+	}
+	return false
+}
+
+// finished returns true if the round is over
+func (d *debugger) finished() bool {
+	count := d.sim.WarriorCount()
+	living := d.sim.WarriorLivingCount()
+	if count > 1 && living < 2 || count == 1 && living == 0 {
+		return true
+	}
+	return d.sim.CycleCount() >= d.sim.MaxCycles()
+}
+
+// run runs the simulator until a breakpoint is reached, the round is over,
+// or the start of cycle stop if it is not negative
+func (d *debugger) run(stop int) {
+	stopID := -1
+	if stop >= 0 {
+		id, err := d.sim.AddBreakpoint(gmars.Breakpoint{Type: gmars.BreakCycle, Cycle: stop})
+		if err == nil {
+			stopID = id
+		}
+	}
+
+	_, event := d.sim.Run()
+	if stopID >= 0 {
+		d.sim.RemoveBreakpoint(stopID)
+	}
+
+	if event != nil && event.ID != stopID {
+		switch event.Type {
+		case gmars.BreakExec:
+			fmt.Fprintf(d.out, "breakpoint: warrior %d at %05d\n", event.WarriorIndex, event.Address)
+		case gmars.BreakWrite:
+			fmt.Fprintf(d.out, "watchpoint: warrior %d wrote %05d\n", event.WarriorIndex, event.Address)
+		case gmars.BreakCycle:
+			fmt.Fprintf(d.out, "breakpoint: cycle %d\n", event.Cycle)
+		}
+	}
+	d.printStatus()
+}
+
+func (d *debugger) step(args []string) error {
+	n := 1
+	if len(args) > 0 {
+		val, err := strconv.Atoi(args[0])
+		if err != nil || val < 1 {
+			return fmt.Errorf("invalid step count '%s'", args[0])
+		}
+		n = val
+	}
+
+	d.run(d.sim.CycleCount() + n)
+	return nil
+}
+
+func (d *debugger) cont() {
+	d.run(-1)
+}
+
+func (d *debugger) setBreak(args []string, set bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing breakpoint")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "cycle":
+		if len(args) != 2 {
+			return fmt.Errorf("missing cycle")
+		}
+		cycle, err := strconv.Atoi(args[1])
+		if err != nil || cycle < 0 {
+			return fmt.Errorf("invalid cycle '%s'", args[1])
+		}
+		if id, ok := d.breakCycles[cycle]; ok {
+			if !set {
+				d.sim.RemoveBreakpoint(id)
+				delete(d.breakCycles, cycle)
+			}
+			return nil
+		}
+		if set {
+			id, err := d.sim.AddBreakpoint(gmars.Breakpoint{Type: gmars.BreakCycle, Cycle: cycle})
+			if err != nil {
+				return err
+			}
+			d.breakCycles[cycle] = id
+		}
+		return nil
+
+	case "watch":
+		if set || len(args) != 2 {
+			return fmt.Errorf("usage: delete watch <addr>")
+		}
+		addr, err := d.parseAddress(args[1])
+		if err != nil {
+			return err
+		}
+		if w, ok := d.watches[addr]; ok {
+			d.sim.RemoveBreakpoint(w.id)
+			delete(d.watches, addr)
+		}
+		return nil
+
+	case "all":
+		if !set {
+			d.sim.ClearBreakpoints()
+			d.breakAddrs = make(map[gmars.Address]int)
+			d.breakCycles = make(map[int]int)
+			d.watches = make(map[gmars.Address]watch)
+			return nil
+		}
+	}
+
+	addr, err := d.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	if id, ok := d.breakAddrs[addr]; ok {
+		if !set {
+			d.sim.RemoveBreakpoint(id)
+			delete(d.breakAddrs, addr)
+		}
+		return nil
+	}
+	if set {
+		id, err := d.sim.AddBreakpoint(gmars.Breakpoint{Type: gmars.BreakExec, Warrior: -1, Start: addr, End: addr})
+		if err != nil {
+			return err
+		}
+		d.breakAddrs[addr] = id
+	}
+	return nil
+}
+
+func (d *debugger) watch(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: watch <addr> [end]")
+	}
+	start, err := d.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	end := start
+	if len(args) == 2 {
+		end, err = d.parseAddress(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	if w, ok := d.watches[start]; ok {
+		d.sim.RemoveBreakpoint(w.id)
+	}
+	id, err := d.sim.AddBreakpoint(gmars.Breakpoint{Type: gmars.BreakWrite, Warrior: -1, Start: start, End: end})
+	if err != nil {
+		return err
+	}
+	d.watches[start] = watch{id: id, end: end}
+	return nil
+}
+
+func (d *debugger) list(args []string) error {
+	start := gmars.Address(0)
+	if len(args) > 0 {
+		addr, err := d.parseAddress(args[0])
+		if err != nil {
+			return err
+		}
+		start = addr
+	} else {
+		// default to the next instruction of the first living warrior
+		for i := 0; i < d.sim.WarriorCount(); i++ {
+			w := d.sim.GetWarrior(i)
+			if pc, err := w.NextPC(); w.Alive() && err == nil {
+				start = pc
+				break
+			}
+		}
+	}
+
+	n := defaultListLength
+	if len(args) > 1 {
+		val, err := strconv.Atoi(args[1])
+		if err != nil || val < 1 {
+			return fmt.Errorf("invalid length '%s'", args[1])
+		}
+		n = val
+	}
+
+	// mark the next instruction of each warrior with its index
+	next := make(map[gmars.Address]string)
+	for i := 0; i < d.sim.WarriorCount(); i++ {
+		w := d.sim.GetWarrior(i)
+		if pc, err := w.NextPC(); w.Alive() && err == nil {
+			next[pc] += strconv.Itoa(i)
+		}
+	}
+
+	coresize := d.sim.CoreSize()
+	for i := 0; i < n; i++ {
+		addr := (start + gmars.Address(i)) % coresize
+		brk := " "
+		if _, ok := d.breakAddrs[addr]; ok {
+			brk = "*"
+		}
+		fmt.Fprintf(d.out, "%s %-3s %05d  %s\n", brk, next[addr], addr, d.sim.GetMem(addr).NormString(coresize))
+	}
+	return nil
+}
+
+func (d *debugger) queue(args []string) error {
+	warriors := make([]int, 0)
+	if len(args) > 0 {
+		wi, err := strconv.Atoi(args[0])
+		if err != nil || wi < 0 || wi >= d.sim.WarriorCount() {
+			return fmt.Errorf("invalid warrior '%s'", args[0])
+		}
+		warriors = append(warriors, wi)
+	} else {
+		for i := 0; i < d.sim.WarriorCount(); i++ {
+			warriors = append(warriors, i)
+		}
+	}
+
+	for _, wi := range warriors {
+		queue := d.sim.GetWarrior(wi).Queue()
+		fmt.Fprintf(d.out, "w%d: %d processes\n", wi, len(queue))
+		for i, pc := range queue {
+			if i%10 == 0 {
+				if i > 0 {
+					fmt.Fprintln(d.out)
+				}
+				fmt.Fprintf(d.out, " ")
+			}
+			fmt.Fprintf(d.out, " %05d", pc)
+		}
+		if len(queue) > 0 {
+			fmt.Fprintln(d.out)
+		}
+	}
+	return nil
+}
+
+func (d *debugger) edit(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: edit <addr> <instruction>")
+	}
+	addr, err := d.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	data, err := gmars.CompileWarrior(strings.NewReader(strings.Join(args[1:], " ")+"\n"), d.config)
+	if err != nil {
+		return err
+	}
+	if len(data.Code) != 1 {
+		return fmt.Errorf("expected 1 instruction, got %d", len(data.Code))
+	}
+
+	snapshot := d.sim.Snapshot()
+	snapshot.Mem[addr] = data.Code[0]
+	err = d.sim.Restore(snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(d.out, "  %05d  %s\n", addr, data.Code[0].NormString(d.sim.CoreSize()))
+	return nil
+}
+
+func (d *debugger) printStatus() {
+	fmt.Fprintf(d.out, "cycle %d\n", d.sim.CycleCount())
+	coresize := d.sim.CoreSize()
+	for i := 0; i < d.sim.WarriorCount(); i++ {
+		w := d.sim.GetWarrior(i)
+		if !w.Alive() {
+			fmt.Fprintf(d.out, "w%d %s: dead\n", i, w.Name())
+			continue
+		}
+		pc, _ := w.NextPC()
+		fmt.Fprintf(d.out, "w%d %s: %d processes, next %05d  %s\n", i, w.Name(), w.ThreadCount(), pc, d.sim.GetMem(pc).NormString(coresize))
+	}
+	if d.finished() {
+		fmt.Fprintf(d.out, "round finished\n")
+	}
+}
+
+func (d *debugger) printBreaks() {
+	addrs := make([]int, 0, len(d.breakAddrs))
+	for addr := range d.breakAddrs {
+		addrs = append(addrs, int(addr))
+	}
+	sort.Ints(addrs)
+	for _, addr := range addrs {
+		fmt.Fprintf(d.out, "break %05d\n", addr)
+	}
+
+	cycles := make([]int, 0, len(d.breakCycles))
+	for cycle := range d.breakCycles {
+		cycles = append(cycles, cycle)
+	}
+	sort.Ints(cycles)
+	for _, cycle := range cycles {
+		fmt.Fprintf(d.out, "break cycle %d\n", cycle)
+	}
+
+	starts := make([]int, 0, len(d.watches))
+	for start := range d.watches {
+		starts = append(starts, int(start))
+	}
+	sort.Ints(starts)
+	for _, start := range starts {
+		fmt.Fprintf(d.out, "watch %05d-%05d\n", start, d.watches[gmars.Address(start)].end)
+	}
+}
+
+// parseAddress parses a core address, folding negative and large values into
+// the core
+func (d *debugger) parseAddress(s string) (gmars.Address, error) {
+	val, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address '%s'", s)
+	}
+	coresize := int(d.sim.CoreSize())
+	return gmars.Address(((val % coresize) + coresize) % coresize), nil
+}
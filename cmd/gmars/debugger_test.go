@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugger(t *testing.T) {
+	config := gmars.ConfigNOP94
+	imp, err := gmars.CompileWarrior(strings.NewReader(";name imp\nmov 0, 1\n"), config)
+	require.NoError(t, err)
+	stone, err := gmars.CompileWarrior(strings.NewReader(";name stone\nspl 0\njmp -1\n"), config)
+	require.NoError(t, err)
+	warriors := []gmars.WarriorData{imp, stone}
+
+	placement := func(round int) (gmars.Placement, error) {
+		return gmars.Placement{Offsets: []gmars.Address{0, gmars.Address(4000 + 100*round)}}, nil
+	}
+
+	tests := []struct {
+		name     string
+		round    int
+		input    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "start",
+			input:    "",
+			contains: []string{"cycle 0\n", "w0 imp: 1 processes, next 00000  MOV.I  $     0 $     1\n"},
+		},
+		{
+			name:     "step",
+			input:    "s 4\n",
+			contains: []string{"cycle 4\n", "w0 imp: 1 processes, next 00004", "w1 stone: 4 processes"},
+		},
+		{
+			name:     "repeat",
+			input:    "step\n\n\n",
+			contains: []string{"cycle 3\n"},
+		},
+		{
+			name:     "break",
+			input:    "break 5\nc\n",
+			contains: []string{"breakpoint: warrior 0 at 00005\n", "next 00005"},
+		},
+		{
+			name:     "break cycle",
+			input:    "b cycle 7\ninfo\ncontinue\n",
+			contains: []string{"break cycle 7\n", "breakpoint: cycle 7\n", "cycle 7\n"},
+		},
+		{
+			name:     "delete",
+			input:    "b 5\nb cycle 9\nd all\ni\ns 10\n",
+			contains: []string{"cycle 10\n"},
+			excludes: []string{"breakpoint:", "break 00005"},
+		},
+		{
+			name:     "watch",
+			input:    "watch 3 4\ncontinue\n",
+			contains: []string{"watchpoint: warrior 0 wrote 00003\n"},
+		},
+		{
+			name:     "list",
+			input:    "list 0 2\n",
+			contains: []string{"  0   00000  MOV.I  $     0 $     1\n      00001  DAT.F  $     0 $     0\n"},
+		},
+		{
+			name:     "queue",
+			input:    "s 2\nqueue 1\n",
+			contains: []string{"w1: 2 processes\n  04000 04000\n"},
+		},
+		{
+			name:     "edit",
+			input:    "edit 0 dat 0, 0\ns\n",
+			contains: []string{"  00000  DAT.F  $     0 $     0\n", "w0 imp: dead\n", "round finished\n"},
+		},
+		{
+			name:     "round",
+			round:    1,
+			input:    "",
+			contains: []string{"w1 stone: 1 processes, next 04100"},
+		},
+		{
+			name:     "quit",
+			input:    "q\ns\n",
+			excludes: []string{"cycle 1\n"},
+		},
+		{
+			name:     "errors",
+			input:    "bogus\ns x\nlist 0 0\nqueue 5\nedit 1\n",
+			contains: []string{"error: unknown command 'bogus'\n", "error: invalid step count 'x'\n", "error: invalid length '0'\n", "error: invalid warrior '5'\n", "error: usage: edit <addr> <instruction>\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &strings.Builder{}
+			err := runDebugger(config, warriors, placement, test.round, strings.NewReader(test.input), out)
+			require.NoError(t, err)
+			for _, text := range test.contains {
+				assert.Contains(t, out.String(), text)
+			}
+			for _, text := range test.excludes {
+				assert.NotContains(t, out.String(), text)
+			}
+		})
+	}
+}
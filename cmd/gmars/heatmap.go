@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bobertlo/gmars"
+)
+
+const (
+	heatmapColumns = 100
+	heatmapScale   = 4
+)
+
+func parseHeatKind(name string) (gmars.HeatKind, error) {
+	for _, kind := range []gmars.HeatKind{gmars.HeatExecute, gmars.HeatWrite, gmars.HeatRead} {
+		if kind.String() == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid heatmap type '%s'", name)
+}
+
+// writeHeatmap merges the counts of each worker and writes them to a PNG file
+func writeHeatmap(filename string, kind gmars.HeatKind, heatmaps []*gmars.HeatmapReporter) error {
+	if len(heatmaps) == 0 {
+		return fmt.Errorf("no rounds played")
+	}
+	total := heatmaps[0]
+	for _, heatmap := range heatmaps[1:] {
+		err := total.Merge(heatmap)
+		if err != nil {
+			return err
+		}
+	}
+
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	err = total.WritePNG(out, kind, heatmapColumns, heatmapScale)
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
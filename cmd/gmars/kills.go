@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bobertlo/gmars"
+)
+
+// printKills prints the processes and deaths of each warrior by the warrior
+// that wrote the instruction that killed them
+func printKills(w io.Writer, warriors []gmars.WarriorData, summaries []gmars.KillSummary) {
+	for wi, summary := range summaries {
+		fmt.Fprintf(w, "w%d %s killed by:\n", wi, warriors[wi].Name)
+		for killer := gmars.NoKiller; killer < len(warriors); killer++ {
+			processes := summary.Processes[killer]
+			deaths := summary.Deaths[killer]
+			if processes == 0 && deaths == 0 {
+				continue
+			}
+
+			name := "empty core"
+			if killer == wi {
+				name = "own code"
+			} else if killer != gmars.NoKiller {
+				name = fmt.Sprintf("w%d %s", killer, warriors[killer].Name)
+			}
+			fmt.Fprintf(w, "  %s: %d processes, %d deaths\n", name, processes, deaths)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"math/rand"
 	"os"
 
 	"github.com/bobertlo/gmars"
@@ -12,26 +12,41 @@ import (
 const (
 	usage = `gMARS %s
 
-Usage: gmars [options] [warrior1.red] [warrior2.red]
+Usage: gmars [options] [warrior1.red] [warrior2.red] ...
+       gmars tournament [options] <warrior_dir>
 `
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "tournament" {
+		runTournament(os.Args[2:])
+		return
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), usage, "v0.1.14")
 		flag.PrintDefaults()
 	}
 
-	use88Flag := flag.Bool("8", false, "Enforce ICWS'88 rules")
-	sizeFlag := flag.Int("s", 8000, "Size of core")
-	procFlag := flag.Int("p", 8000, "Max. Processes")
-	cycleFlag := flag.Int("c", 80000, "Cycles until tie")
-	lenFlag := flag.Int("l", 100, "Max. warrior length")
+	configFlags := addConfigFlags(flag.CommandLine)
 	fixedFlag := flag.Int("F", 0, "fixed position of warrior #2")
+	permuteFlag := flag.Bool("P", false, "Try every position and starting order of warrior #2")
 	roundFlag := flag.Int("r", 1, "Rounds to play")
+	flag.Int64("seed", 0, "Seed for warrior placement (default: random)")
+	workersFlag := flag.Int("j", 0, "Number of parallel workers (default: number of CPUs)")
 	debugFlag := flag.Bool("debug", false, "Dump verbose reporting of simulator state")
+	traceFlag := flag.String("trace", "", "Write a JSON Lines trace of every report to a file")
+	recordFlag := flag.String("record", "", "Write a binary recording of the battle to a file")
+	statsFlag := flag.Bool("stats", false, "Print execution statistics of each warrior")
+	killsFlag := flag.Bool("kills", false, "Print which warrior wrote the instructions that killed each warrior")
+	heatmapFlag := flag.String("heatmap", "", "Write a PNG heatmap of core accesses to a file")
+	heatmapTypeFlag := flag.String("heatmap-type", "write", "Type of core access in the heatmap: exec, write or read")
+	timelineFlag := flag.String("timeline", "", "Write the process count of each warrior over time to a CSV or .json file")
+	timelineIntervalFlag := flag.Int("timeline-interval", 100, "Cycles between timeline samples")
+	timelineAggregateFlag := flag.Bool("timeline-aggregate", false, "Write the timeline averaged over every round")
+	debuggerFlag := flag.Bool("e", false, "Run a round in the interactive debugger")
+	debuggerRoundFlag := flag.Int("e-round", 0, "Round to run in the debugger, starting with empty P-space")
 	assembleFlag := flag.Bool("A", false, "Assemble and output warriors only")
-	presetFlag := flag.String("preset", "", "Load named preset config (and ignore other flags)")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
 	flag.Parse()
 
@@ -40,32 +55,22 @@ func main() {
 		os.Exit(0)
 	}
 
-	var config gmars.SimulatorConfig
-	if *presetFlag != "" {
-		presetConfig, err := gmars.PresetConfig(*presetFlag)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error loading config: %s\n", err)
-			os.Exit(1)
-		}
-		config = presetConfig
-	} else {
-		var mode gmars.SimulatorMode
-		if *use88Flag {
-			mode = gmars.ICWS88
-		} else {
-			mode = gmars.ICWS94
-		}
-		coresize := gmars.Address(*sizeFlag)
-		processes := gmars.Address(*procFlag)
-		cycles := gmars.Address(*cycleFlag)
-		length := gmars.Address(*lenFlag)
-		config = gmars.NewQuickConfig(mode, coresize, processes, cycles, length)
+	config, err := configFlags.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %s\n", err)
+		os.Exit(1)
 	}
 
 	args := flag.Args()
+	config.Warriors = len(args)
+	config.Rounds = *roundFlag
 
-	if len(args) > 2 {
-		fmt.Fprintf(os.Stderr, "only 2 warrior battles supported")
+	if *fixedFlag != 0 && len(args) > 2 {
+		fmt.Fprintf(os.Stderr, "fixed position is only supported in 2 warrior battles\n")
+		os.Exit(1)
+	}
+	if *permuteFlag && (len(args) != 2 || *fixedFlag != 0) {
+		fmt.Fprintf(os.Stderr, "permutations are only supported in 2 warrior battles without a fixed position\n")
 		os.Exit(1)
 	}
 
@@ -108,81 +113,192 @@ func main() {
 		return
 	}
 
-	rounds := *roundFlag
+	heatKind, err := parseHeatKind(*heatmapTypeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	if *timelineAggregateFlag && isJSONFile(*timelineFlag) {
+		fmt.Fprintf(os.Stderr, "timeline aggregate is only supported for CSV output, JSON output always includes it\n")
+		os.Exit(1)
+	}
+	if *timelineIntervalFlag < 1 {
+		fmt.Fprintf(os.Stderr, "timeline interval must be at least 1\n")
+		os.Exit(1)
+	}
+
+	seed := gmars.FlagSeed(flag.CommandLine, "seed")
+	fmt.Fprintf(os.Stderr, "seed: %d\n", seed)
 
-	w1win := 0
-	w1tie := 0
-	w2win := 0
-	w2tie := 0
-	for i := 0; i < rounds; i++ {
-		sim, err := gmars.NewReportingSimulator(config)
+	placement := gmars.SeededPlacement(config, len(warriors), seed)
+	if *permuteFlag {
+		placement, err = gmars.PermutationPlacement(config, seed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error creating permutations: %s\n", err)
+			os.Exit(1)
+		}
+	} else if *fixedFlag != 0 && len(warriors) > 1 {
+		placement = gmars.FixedPlacement(placement, gmars.Address(*fixedFlag))
+	}
+
+	if *debuggerFlag {
+		if *traceFlag != "" || *recordFlag != "" {
+			fmt.Fprintf(os.Stderr, "tracing and recording are not supported in the debugger\n")
+			os.Exit(1)
+		}
+		if *debuggerRoundFlag < 0 {
+			fmt.Fprintf(os.Stderr, "invalid debugger round %d\n", *debuggerRoundFlag)
+			os.Exit(1)
+		}
+		err := runDebugger(config, warriors, placement, *debuggerRoundFlag, os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error running debugger: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	runner, err := gmars.NewBattleRunner(config, warriors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating battle runner: %s\n", err)
+		os.Exit(1)
+	}
+	// debug output, traces and recordings follow the rounds of a single
+	// simulator
+	if *debugFlag || *traceFlag != "" || *recordFlag != "" {
+		runner.SetWorkers(1)
+	} else {
+		runner.SetWorkers(*workersFlag)
+	}
+	var trace *gmars.TraceReporter
+	if *traceFlag != "" {
+		traceFile, err := os.Create(*traceFlag)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error creating sim: %s", err)
+			fmt.Fprintf(os.Stderr, "error creating trace file: %s\n", err)
+			os.Exit(1)
+		}
+		defer traceFile.Close()
+		trace = gmars.NewTraceReporter(traceFile, config, warriors)
+	}
+	var recording *gmars.RecordingWriter
+	if *recordFlag != "" {
+		recordFile, err := os.Create(*recordFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error creating recording file: %s\n", err)
+			os.Exit(1)
+		}
+		defer recordFile.Close()
+		recording, err = gmars.NewRecordingWriter(recordFile, config, warriors)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error writing recording file: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	// the reporter function is called for each worker in order, and the
+	// workers run consecutive blocks of rounds, so the rounds of the
+	// reporters are in order when they are joined in the order created
+	stats := make([]*gmars.StatsReporter, 0)
+	kills := make([]*gmars.KillReporter, 0)
+	heatmaps := make([]*gmars.HeatmapReporter, 0)
+	timelines := make([]*gmars.TimelineReporter, 0)
+	runner.SetReporterFunc(func(sim gmars.ReportingSimulator) {
+		if *statsFlag {
+			reporter := gmars.NewStatsReporter(sim)
+			sim.AddReporter(reporter)
+			stats = append(stats, reporter)
+		}
+		if *killsFlag {
+			reporter := gmars.NewKillReporter(sim)
+			sim.AddReporter(reporter)
+			kills = append(kills, reporter)
+		}
+		if *heatmapFlag != "" {
+			reporter := gmars.NewHeatmapReporter(sim)
+			sim.AddReporter(reporter)
+			heatmaps = append(heatmaps, reporter)
+		}
+		if *timelineFlag != "" {
+			reporter, _ := gmars.NewTimelineReporter(sim, *timelineIntervalFlag)
+			sim.AddReporter(reporter)
+			timelines = append(timelines, reporter)
 		}
 		if *debugFlag {
 			sim.AddReporter(gmars.NewDebugReporter(sim))
 		}
-
-		w2start := *fixedFlag
-		if w2start == 0 {
-			minStart := 2 * config.Length
-			maxStart := config.CoreSize - config.Length - 1
-			startRange := maxStart - minStart
-			w2start = rand.Intn(int(startRange)+1) + int(minStart)
+		if trace != nil {
+			sim.AddReporter(trace)
+		}
+		if recording != nil {
+			sim.AddReporter(recording)
 		}
+	})
+	runner.SetPlacement(placement)
 
-		w1, err := sim.AddWarrior(&warriors[0])
+	result, err := runner.Run(context.Background(), *roundFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error running battle: %s\n", err)
+		os.Exit(1)
+	}
+	if trace != nil {
+		err := trace.Flush()
 		if err != nil {
-			fmt.Printf("error adding warrior 1: %s", err)
+			fmt.Fprintf(os.Stderr, "error writing trace file: %s\n", err)
 			os.Exit(1)
 		}
-		err = sim.SpawnWarrior(0, 0)
+	}
+	if recording != nil {
+		err := recording.Flush()
 		if err != nil {
-			fmt.Printf("error adding warrior 1: %s", err)
+			fmt.Fprintf(os.Stderr, "error writing recording file: %s\n", err)
 			os.Exit(1)
 		}
+	}
 
-		var w2 gmars.Warrior
-		if len(warriors) > 1 {
-			w, err := sim.AddWarrior(&warriors[1])
-			if err != nil {
-				fmt.Printf("error adding warrior 2: %s", err)
-				os.Exit(1)
-			}
-			err = sim.SpawnWarrior(1, gmars.Address(w2start))
-			if err != nil {
-				fmt.Printf("error spawning warrior 1: %s", err)
-				os.Exit(1)
-			}
-			w2 = w
+	// two warrior battles print wins and ties, and multi-warrior battles
+	// also print the total score of each warrior
+	for wi := range warriors {
+		if len(warriors) > 2 {
+			fmt.Printf("%d %d %d\n", result.Wins[wi], result.Ties[wi], result.Scores[wi])
+		} else {
+			fmt.Printf("%d %d\n", result.Wins[wi], result.Ties[wi])
 		}
+	}
 
-		sim.Run()
+	if *statsFlag {
+		rounds := make([][]gmars.WarriorStats, 0, *roundFlag)
+		for _, reporter := range stats {
+			rounds = append(rounds, reporter.Rounds()...)
+		}
+		printStats(os.Stdout, warriors, gmars.SummarizeStats(rounds))
+	}
 
-		if len(warriors) == 1 {
-			if w1.Alive() {
-				w1win += 1
-			}
-		} else if len(warriors) == 2 {
-			if w1.Alive() {
-				if w2.Alive() {
-					w1tie += 1
-				} else {
-					w1win += 1
-				}
-			}
+	if *killsFlag {
+		events := make([]gmars.KillEvent, 0)
+		for _, reporter := range kills {
+			events = append(events, reporter.Events()...)
+		}
+		printKills(os.Stdout, warriors, gmars.SummarizeKills(events))
+	}
 
-			if w2.Alive() {
-				if w1.Alive() {
-					w2tie += 1
-				} else {
-					w2win += 1
-				}
-			}
+	if *heatmapFlag != "" {
+		err := writeHeatmap(*heatmapFlag, heatKind, heatmaps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error writing heatmap: %s\n", err)
+			os.Exit(1)
 		}
 	}
-	fmt.Printf("%d %d\n", w1win, w1tie)
-	if len(warriors) > 1 {
-		fmt.Printf("%d %d\n", w2win, w2tie)
+
+	if *timelineFlag != "" {
+		rounds := make([][]gmars.TimelineSample, 0, *roundFlag)
+		for _, reporter := range timelines {
+			rounds = append(rounds, reporter.Rounds()...)
+		}
+		timeline := gmars.NewTimeline(*timelineIntervalFlag, rounds)
+		err := writeTimeline(*timelineFlag, timeline, *timelineAggregateFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error writing timeline: %s\n", err)
+			os.Exit(1)
+		}
 	}
 }
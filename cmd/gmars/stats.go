@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bobertlo/gmars"
+)
+
+// printStats prints the execution statistics of each warrior summed over
+// every round
+func printStats(w io.Writer, warriors []gmars.WarriorData, summaries []gmars.StatsSummary) {
+	for wi, summary := range summaries {
+		fmt.Fprintf(w, "w%d %s:\n", wi, warriors[wi].Name)
+
+		ops := make([]gmars.OpCode, 0, len(summary.Executed))
+		for op := range summary.Executed {
+			ops = append(ops, op)
+		}
+		sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+		fmt.Fprintf(w, "  executed:")
+		for _, op := range ops {
+			fmt.Fprintf(w, " %s %d", op, summary.Executed[op])
+		}
+		fmt.Fprintf(w, "\n")
+
+		fmt.Fprintf(w, "  writes: %d to opponents, %d elsewhere\n", summary.OpponentWrites, summary.OtherWrites)
+		fmt.Fprintf(w, "  processes: %d peak, %.2f average\n", summary.PeakProcesses, summary.AverageProcesses())
+		fmt.Fprintf(w, "  deaths: %d of %d rounds, average cycle %.0f\n", summary.Deaths, summary.Rounds, summary.AverageDeathCycle())
+		fmt.Fprintf(w, "  processes ended by DAT: %d\n", summary.DATTerminations)
+	}
+}
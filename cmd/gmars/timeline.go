@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/bobertlo/gmars"
+)
+
+// writeTimeline writes the timeline to a file as JSON if the file name ends
+// in .json and as CSV otherwise. The JSON output always holds every round
+// and the aggregate, so aggregate only applies to the CSV output.
+func writeTimeline(filename string, timeline gmars.Timeline, aggregate bool) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch {
+	case isJSONFile(filename):
+		err = timeline.WriteJSON(out)
+	case aggregate:
+		err = timeline.WriteAggregateCSV(out)
+	default:
+		err = timeline.WriteCSV(out)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// isJSONFile returns true if a timeline written to filename is JSON
+func isJSONFile(filename string) bool {
+	return filepath.Ext(filename) == ".json"
+}
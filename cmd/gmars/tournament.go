@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bobertlo/gmars"
+)
+
+const (
+	tournamentUsage = `gMARS %s
+
+Usage: gmars tournament [options] <warrior_dir>
+
+Plays a round robin tournament between every .red file in warrior_dir.
+`
+)
+
+func runTournament(args []string) {
+	fs := flag.NewFlagSet("tournament", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), tournamentUsage, "v0.1.14")
+		fs.PrintDefaults()
+	}
+
+	configFlags := addConfigFlags(fs)
+	roundFlag := fs.Int("r", 100, "Rounds to play in each battle")
+	workersFlag := fs.Int("j", 0, "Number of battles to run in parallel (default: number of CPUs)")
+	fs.Int64("seed", 0, "Seed for warrior placement (default: random)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	config, err := configFlags.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %s\n", err)
+		os.Exit(1)
+	}
+	config.Rounds = *roundFlag
+
+	tournament, err := gmars.NewTournament(config, *roundFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating tournament: %s\n", err)
+		os.Exit(1)
+	}
+
+	tournament.SetWorkers(*workersFlag)
+	tournament.SetSeed(gmars.FlagSeed(fs, "seed"))
+	fmt.Fprintf(os.Stderr, "seed: %d\n", tournament.Seed())
+
+	err = tournament.LoadDir(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading warriors: %s\n", err)
+		os.Exit(1)
+	}
+
+	results, err := tournament.Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error running tournament: %s\n", err)
+		os.Exit(1)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Rank\tScore\tWins\tLosses\tTies\t\tName\n")
+	for i, result := range results {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t\t%s\n", i+1, result.Score, result.Wins, result.Losses, result.Ties, result.Name)
+	}
+	tw.Flush()
+}
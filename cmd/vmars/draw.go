@@ -28,7 +28,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	const xCount = screenWidth / tileSize
 
-	for i := 0; i < int(g.sim.CoreSize()); i++ {
+	for i := 0; i < int(g.view.CoreSize()); i++ {
 		state, color := g.rec.GetMemState(gmars.Address(i))
 
 		if state == gmars.CoreEmpty {
@@ -44,10 +44,10 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		screen.DrawImage(tilesImage.SubImage(image.Rect(sx, sy, sx+tileSize, sy+tileSize)).(*ebiten.Image), op)
 	}
 
-	for i := 0; i < int(g.sim.WarriorCount()); i++ {
+	for i := 0; i < int(g.view.WarriorCount()); i++ {
 		t := 0
 
-		w := g.sim.GetWarrior(i)
+		w := g.view.GetWarrior(i)
 		if w == nil || !w.Alive() {
 			continue
 		}
@@ -83,7 +83,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		Size:   10,
 	}, op)
 
-	msg = fmt.Sprintf("Cycle: %05d (%dx)", g.sim.CycleCount(), speeds[g.speedStep])
+	msg = fmt.Sprintf("Cycle: %05d (%dx)", g.view.CycleCount(), speeds[g.speedStep])
 	op = &text.DrawOptions{}
 	op.GeoM.Translate(5, 465)
 	op.ColorScale.ScaleWithColor(color.White)
@@ -94,9 +94,9 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	// draw results if finished
 	if g.finished {
-		if g.sim.WarriorCount() > 1 {
-			w1a := g.sim.GetWarrior(0).Alive()
-			w2a := g.sim.GetWarrior(1).Alive()
+		if g.view.WarriorCount() > 1 {
+			w1a := g.view.GetWarrior(0).Alive()
+			w2a := g.view.GetWarrior(1).Alive()
 
 			if w1a || w2a {
 
@@ -108,10 +108,10 @@ func (g *Game) Draw(screen *ebiten.Image) {
 					msg = "tie"
 				} else if w1a {
 					op.ColorScale = warriorColors[1]
-					msg = fmt.Sprintf("%s wins", g.sim.GetWarrior(0).Name())
+					msg = fmt.Sprintf("%s wins", g.view.GetWarrior(0).Name())
 				} else if w2a {
 					op.ColorScale = warriorColors[2]
-					msg = fmt.Sprintf("%s wins", g.sim.GetWarrior(1).Name())
+					msg = fmt.Sprintf("%s wins", g.view.GetWarrior(1).Name())
 				}
 				text.Draw(screen, msg, &text.GoTextFace{
 					Source: mplusFaceSource,
@@ -2,28 +2,77 @@ package main
 
 import (
 	"errors"
-	"math/rand"
+	"fmt"
 
 	"github.com/bobertlo/gmars"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+// battle is the state of a battle shown by Draw, which comes from either a
+// simulator or a replay
+type battle interface {
+	CoreSize() gmars.Address
+	CycleCount() int
+	WarriorCount() int
+	GetWarrior(wi int) gmars.Warrior
+}
+
 type Game struct {
-	sim        gmars.ReportingSimulator
-	config     gmars.SimulatorConfig
-	fixedStart int
-	rec        gmars.StateRecorder
-	running    bool
-	finished   bool
-	speedStep  int
-	counter    int
+	view      battle
+	sim       gmars.ReportingSimulator
+	replay    *gmars.Replay
+	config    gmars.SimulatorConfig
+	placement gmars.PlacementFunc
+	round     int
+	rec       *gmars.StateRecorder
+	history   *gmars.HistoryRecorder
+	running   bool
+	rewinding bool
+	finished  bool
+	speedStep int
+	counter   int
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return screenWidth, screenHeight
 }
 
+// spawnWarriors spawns the warriors at the placement of the current round
+func (g *Game) spawnWarriors() error {
+	placement, err := g.placement(g.round)
+	if err != nil {
+		return err
+	}
+	for i, offset := range placement.Offsets {
+		err := g.sim.SpawnWarrior(i, offset)
+		if err != nil {
+			return err
+		}
+	}
+	return g.sim.SetFirstWarrior(placement.First)
+}
+
+// nextRound resets the simulator and spawns the warriors for the next
+// round, or skips to the next round of a replay
+func (g *Game) nextRound() error {
+	if g.replay != nil {
+		if !g.replay.NextRound() {
+			return nil
+		}
+	} else {
+		g.sim.Reset()
+		g.round++
+		err := g.spawnWarriors()
+		if err != nil {
+			return fmt.Errorf("error placing warriors: %s", err)
+		}
+	}
+	g.finished = false
+	g.rewinding = false
+	return nil
+}
+
 func (g *Game) slowDown() {
 	g.speedStep--
 	if g.speedStep < 0 {
@@ -38,40 +87,37 @@ func (g *Game) speedUp() {
 	}
 }
 
-func (g *Game) handleInput() {
+func (g *Game) handleInput() error {
 	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
 		g.running = !g.running
+		g.rewinding = false
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
+		g.rewinding = !g.rewinding
+		g.running = false
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyR) {
-		g.sim.Reset()
-		g.sim.SpawnWarrior(0, 0)
-
-		if g.sim.WarriorCount() > 1 {
-			w2start := g.fixedStart
-			if w2start == 0 {
-				minStart := 2 * g.config.Length
-				maxStart := g.config.CoreSize - g.config.Length - 1
-				startRange := maxStart - minStart
-				w2start = rand.Intn(int(startRange)+1) + int(minStart)
-			}
-			g.sim.SpawnWarrior(1, gmars.Address(w2start))
-		}
-
-		g.finished = false
+		return g.nextRound()
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
 		g.slowDown()
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
 		g.speedUp()
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
-		g.running = false
+		if g.running || g.rewinding {
+			g.running = false
+			g.rewinding = false
+		} else {
+			return g.stepBack(g.frameCycles())
+		}
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
-		if g.running {
+		if g.running || g.rewinding {
 			g.running = false
+			g.rewinding = false
 		} else {
 			for i := 0; i < speeds[g.speedStep]; i++ {
 				g.runCycle()
 			}
 		}
 	}
+	return nil
 }
 
 func (g *Game) Update() error {
@@ -91,9 +137,26 @@ func (g *Game) Update() error {
 				g.runCycle()
 			}
 		}
+	} else if g.rewinding {
+		if speed < 0 {
+			if g.counter%speed == 0 {
+				err := g.stepBack(1)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			err := g.stepBack(speed)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	g.handleInput()
+	err := g.handleInput()
+	if err != nil {
+		return err
+	}
 
 	g.counter++
 
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"image"
 	"log"
-	"math/rand"
 	"os"
 
 	"github.com/bobertlo/gmars"
@@ -73,8 +72,11 @@ func main() {
 	cycleFlag := flag.Int("c", 80000, "Cycles until tie")
 	lenFlag := flag.Int("l", 100, "Max. warrior length")
 	fixedFlag := flag.Int("F", 0, "fixed position of warrior #2")
+	flag.Int64("seed", 0, "Seed for warrior placement (default: random)")
 	// roundFlag := flag.Int("r", 1, "Rounds to play")
 	showReadFlag := flag.Bool("showread", false, "display reads in the visualizer")
+	historyFlag := flag.Int("history", 80000, "Cycles of history kept for stepping back")
+	replayFlag := flag.String("replay", "", "Play back a recording made with gmars -record")
 	debugFlag := flag.Bool("debug", false, "Dump verbose reporting of simulator state")
 	presetFlag := flag.String("preset", "", "Load named preset config (and ignore other flags)")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
@@ -85,6 +87,16 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *replayFlag != "" {
+		game, warriors, err := newReplayGame(*replayFlag, *showReadFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading recording: %s\n", err)
+			os.Exit(1)
+		}
+		runGame(game, warriors)
+		return
+	}
+
 	var config gmars.SimulatorConfig
 	if *presetFlag != "" {
 		presetConfig, err := gmars.PresetConfig(*presetFlag)
@@ -116,6 +128,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "usage: vmars <warrior1.red> [warrior2]\nloading demo warriors...\n")
 	}
 
+	config.Warriors = len(args)
+
 	warriors := make([]gmars.WarriorData, 0)
 	if len(args) == 0 {
 		for _, data := range [][]byte{gmars.BombSpiral_94_red, gmars.SimpleShot_94_red} {
@@ -155,35 +169,44 @@ func main() {
 	}
 	rec := gmars.NewStateRecorder(sim)
 	rec.SetRecordRead(*showReadFlag)
-	sim.AddReporter(rec)
+	history := gmars.NewHistoryRecorder(sim, rec, *historyFlag)
+	sim.AddReporter(history)
 
 	sim.AddWarrior(&warriors[0])
 	if len(warriors) > 1 {
 		sim.AddWarrior(&warriors[1])
 	}
 
-	sim.SpawnWarrior(0, 0)
+	seed := gmars.FlagSeed(flag.CommandLine, "seed")
+	fmt.Fprintf(os.Stderr, "seed: %d\n", seed)
 
-	if len(warriors) > 1 {
-		w2start := *fixedFlag
-		if w2start == 0 {
-			minStart := 2 * config.Length
-			maxStart := config.CoreSize - config.Length - 1
-			startRange := maxStart - minStart
-			w2start = rand.Intn(int(startRange)+1) + int(minStart)
-		}
-		sim.SpawnWarrior(1, gmars.Address(w2start))
+	placement := gmars.SeededPlacement(config, len(warriors), seed)
+	if *fixedFlag != 0 && len(warriors) > 1 {
+		placement = gmars.FixedPlacement(placement, gmars.Address(*fixedFlag))
 	}
 
 	game := &Game{
-		sim:        sim,
-		config:     config,
-		rec:        *rec,
-		speedStep:  defaultSpeedStep,
-		fixedStart: *fixedFlag,
-		running:    true,
+		view:      sim,
+		sim:       sim,
+		config:    config,
+		rec:       rec,
+		history:   history,
+		placement: placement,
+		speedStep: defaultSpeedStep,
+		running:   true,
 	}
 
+	err = game.spawnWarriors()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error placing warriors: %s\n", err)
+		os.Exit(1)
+	}
+
+	runGame(game, warriors)
+}
+
+// runGame opens the window and runs the game until it is closed
+func runGame(game *Game, warriors []gmars.WarriorData) {
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	if len(warriors) > 1 {
 		ebiten.SetWindowTitle(fmt.Sprintf("gMARS - '%s' vs '%s'", warriors[0].Name, warriors[1].Name))
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bobertlo/gmars"
+)
+
+// newReplayGame creates a Game playing back the recording in the named file,
+// starting at the first round. The file is read as the replay is played, so
+// it is left open.
+func newReplayGame(name string, showRead bool) (*Game, []gmars.WarriorData, error) {
+	in, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := gmars.NewRecordingReader(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(reader.Warriors) > 2 {
+		return nil, nil, fmt.Errorf("only 2 warrior battles supported")
+	}
+
+	replay, err := gmars.NewReplay(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	rec := gmars.NewStateRecorder(replay)
+	rec.SetRecordRead(showRead)
+	replay.AddReporter(rec)
+
+	if !replay.NextRound() {
+		if err := replay.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("recording is empty")
+	}
+
+	game := &Game{
+		view:      replay,
+		replay:    replay,
+		config:    reader.Config,
+		rec:       rec,
+		speedStep: defaultSpeedStep,
+		running:   true,
+	}
+	return game, reader.Warriors, nil
+}
@@ -1,10 +1,25 @@
 package main
 
+import (
+	"fmt"
+	"log"
+)
+
 func (g *Game) runCycle() {
 	if g.finished {
 		return
 	}
 
+	if g.replay != nil {
+		if !g.replay.RunCycle() {
+			g.finished = true
+			if err := g.replay.Err(); err != nil {
+				log.Printf("error reading recording: %s", err)
+			}
+		}
+		return
+	}
+
 	count := g.sim.WarriorCount()
 	living := g.sim.WarriorLivingCount()
 	if ((count > 1 && living > 1) || (count < 2 && living > 0)) && g.sim.CycleCount() < g.sim.MaxCycles() {
@@ -13,3 +28,30 @@ func (g *Game) runCycle() {
 		g.finished = true
 	}
 }
+
+// frameCycles returns the number of cycles in one frame at the current speed
+func (g *Game) frameCycles() int {
+	speed := speeds[g.speedStep]
+	if speed < 1 {
+		return 1
+	}
+	return speed
+}
+
+// stepBack steps the simulator back n cycles using the recorded history and
+// stops rewinding when there is no history left. Replays have no history.
+func (g *Game) stepBack(n int) error {
+	if g.history == nil {
+		g.rewinding = false
+		return nil
+	}
+	stepped, err := g.history.StepBack(n)
+	if err != nil {
+		return fmt.Errorf("error stepping back: %s", err)
+	}
+	if stepped < n {
+		g.rewinding = false
+	}
+	g.finished = false
+	return nil
+}
@@ -14,7 +14,10 @@ type compiler struct {
 	values    map[string][]token // symbols that represent expressions
 	labels    map[string]int     // symbols that represent addresses
 	startExpr []token
+	startLine int // code line of the org or end setting the start
 	metadata  WarriorData
+	registers registers   // values of the registers a to z
+	assigned  registerSet // registers assigned by the warrior
 }
 
 func newCompiler(src []sourceLine, metadata WarriorData, config SimulatorConfig) (*compiler, error) {
@@ -31,12 +34,30 @@ func newCompiler(src []sourceLine, metadata WarriorData, config SimulatorConfig)
 	}, nil
 }
 
-func (c *compiler) loadConstants() {
-	c.values["CORESIZE"] = []token{{tokNumber, fmt.Sprintf("%d", c.config.CoreSize)}}
-	c.values["MAXLENGTH"] = []token{{tokNumber, fmt.Sprintf("%d", c.config.Length)}}
-	c.values["MAXPROCESSES"] = []token{{tokNumber, fmt.Sprintf("%d", c.config.Processes)}}
-	c.values["MINDISTANCE"] = []token{{tokNumber, fmt.Sprintf("%d", c.config.Distance)}}
-	// c.values["CURLINE"] = []token{{tokNumber, "0"}}
+// pmarsVersion is the value of the VERSION constant, matching pMARS 0.9.4
+const pmarsVersion = 94
+
+// predefinedConstants returns the values of the pMARS predefined constants
+// for config. CURLINE is not included because it depends on the line it is
+// used in, so it is replaced in expandExpression.
+func predefinedConstants(config SimulatorConfig) map[string][]token {
+	values := make(map[string][]token)
+	for name, value := range map[string]int{
+		"CORESIZE":     int(config.CoreSize),
+		"MAXLENGTH":    int(config.Length),
+		"MAXPROCESSES": int(config.Processes),
+		"MINDISTANCE":  int(config.Distance),
+		"MAXCYCLES":    int(config.Cycles),
+		"PSPACESIZE":   int(config.pspaceSize()),
+		"READLIMIT":    int(config.ReadLimit),
+		"WRITELIMIT":   int(config.WriteLimit),
+		"WARRIORS":     config.warriorCount(),
+		"ROUNDS":       config.roundCount(),
+		"VERSION":      pmarsVersion,
+	} {
+		values[name] = []token{{tokNumber, fmt.Sprintf("%d", value)}}
+	}
+	return values
 }
 
 // load symbol []token values into value map and code line numbers of
@@ -44,8 +65,11 @@ func (c *compiler) loadConstants() {
 func (c *compiler) loadSymbols() {
 	c.values = make(map[string][]token)
 	c.labels = make(map[string]int)
+	c.assigned = lineRegisters(c.lines)
 
-	c.loadConstants()
+	for name, value := range predefinedConstants(c.config) {
+		c.values[name] = value
+	}
 
 	curPseudoLine := 0
 	for _, line := range c.lines {
@@ -56,9 +80,11 @@ func (c *compiler) loadSymbols() {
 				}
 			} else if strings.ToLower(line.op) == "org" {
 				c.startExpr = line.a
+				c.startLine = curPseudoLine
 			} else if strings.ToLower(line.op) == "end" {
 				if len(line.a) > 0 {
 					c.startExpr = line.a
+					c.startLine = curPseudoLine
 				}
 				for _, label := range line.labels {
 					c.labels[label] = curPseudoLine
@@ -75,7 +101,9 @@ func (c *compiler) loadSymbols() {
 	}
 }
 
-func (c *compiler) expandExpression(expr []token, line int) ([]token, error) {
+// expandExpression replaces the symbols in expr, with labels relative to line
+// and CURLINE evaluating to curLine
+func (c *compiler) expandExpression(expr []token, line, curLine int) ([]token, error) {
 	input := expr
 	var output []token
 
@@ -87,6 +115,11 @@ func (c *compiler) expandExpression(expr []token, line int) ([]token, error) {
 		output = make([]token, 0)
 		for _, tok := range input {
 			if tok.typ == tokText {
+				if tok.val == "CURLINE" {
+					output = append(output, token{tokNumber, fmt.Sprintf("%d", curLine)})
+					continue
+				}
+
 				val, valOk := c.values[tok.val]
 				if valOk {
 					output = append(output, val...)
@@ -101,6 +134,9 @@ func (c *compiler) expandExpression(expr []token, line int) ([]token, error) {
 					} else {
 						output = append(output, token{tokNumber, fmt.Sprintf("%d", val)})
 					}
+				} else if c.assigned[tok.val] {
+					// registers are read when the expression is evaluated
+					output = append(output, tok)
 				} else {
 					return nil, fmt.Errorf("unresolved symbol '%s'", tok.val)
 				}
@@ -112,18 +148,20 @@ func (c *compiler) expandExpression(expr []token, line int) ([]token, error) {
 	return output, nil
 }
 
-func (c *compiler) evaluateAssertion(assertText string) error {
+// evaluateAssertion evaluates an assertion after curLine instructions
+func (c *compiler) evaluateAssertion(assertText string, curLine int) error {
 
 	assertTokens, err := LexInput(strings.NewReader(assertText))
 	if err != nil {
 		return err
 	}
 	assertTokens = assertTokens[:len(assertTokens)-1]
-	exprTokens, err := c.expandExpression(assertTokens, 0)
+	c.assigned.add(assertTokens)
+	exprTokens, err := c.expandExpression(assertTokens, 0, curLine)
 	if err != nil {
 		return err
 	}
-	exprVal, err := evaluateExpression(exprTokens)
+	exprVal, err := evaluateRegisters(exprTokens, &c.registers)
 	if err != nil {
 		return err
 	}
@@ -134,13 +172,17 @@ func (c *compiler) evaluateAssertion(assertText string) error {
 }
 
 func (c *compiler) evaluateAssertions() error {
+	curLine := 0
 	for _, line := range c.lines {
+		if line.typ == lineInstruction {
+			curLine++
+		}
 		if line.typ != lineComment {
 			continue
 		}
 		if strings.HasPrefix(line.comment, ";assert") {
 			assertText := line.comment[7:]
-			err := c.evaluateAssertion(assertText)
+			err := c.evaluateAssertion(assertText, curLine)
 			if err != nil {
 				return err
 			}
@@ -208,11 +250,11 @@ func (c *compiler) assembleLine(in sourceLine) (Instruction, error) {
 		}
 	}
 
-	aExpr, err := c.expandExpression(in.a, in.codeLine)
+	aExpr, err := c.expandExpression(in.a, in.codeLine, in.codeLine)
 	if err != nil {
 		return Instruction{}, err
 	}
-	aVal, err := evaluateExpression(aExpr)
+	aVal, err := evaluateRegisters(aExpr, &c.registers)
 	if err != nil {
 		return Instruction{}, err
 	}
@@ -228,11 +270,11 @@ func (c *compiler) assembleLine(in sourceLine) (Instruction, error) {
 			aVal = 0
 		}
 	} else {
-		bExpr, err := c.expandExpression(in.b, in.codeLine)
+		bExpr, err := c.expandExpression(in.b, in.codeLine, in.codeLine)
 		if err != nil {
 			return Instruction{}, err
 		}
-		b, err := evaluateExpression(bExpr)
+		b, err := evaluateRegisters(bExpr, &c.registers)
 		if err != nil {
 			return Instruction{}, err
 		}
@@ -289,14 +331,13 @@ func (c *compiler) compile() (WarriorData, error) {
 			return WarriorData{}, fmt.Errorf("line %d: %s", line.line, err)
 		}
 		code = append(code, instruction)
-		// c.values["CURLINE"] = []token{{tokNumber, fmt.Sprintf("%d", len(code))}}
 	}
 
-	startExpr, err := c.expandExpression(c.startExpr, 0)
+	startExpr, err := c.expandExpression(c.startExpr, 0, c.startLine)
 	if err != nil {
 		return WarriorData{}, fmt.Errorf("invalid start expression")
 	}
-	startVal, err := evaluateExpression(startExpr)
+	startVal, err := evaluateRegisters(startExpr, &c.registers)
 	if err != nil {
 		return WarriorData{}, fmt.Errorf("invalid start expression: %s", err)
 	}
@@ -317,26 +358,22 @@ func CompileWarrior(r io.Reader, config SimulatorConfig) (WarriorData, error) {
 		return WarriorData{}, err
 	}
 
-	depth := 0
-	for {
-		symbols, forSeen, err := ScanInput(newBufTokenReader(tokens))
-		if err != nil {
-			return WarriorData{}, fmt.Errorf("symbol scanner: %s", err)
-		}
-		if forSeen {
-			expandedTokens, err := ForExpand(newBufTokenReader(tokens), symbols)
-			if err != nil {
-				return WarriorData{}, fmt.Errorf("for: %s", err)
+	symbols, forSeen, err := ScanInput(newBufTokenReader(tokens))
+	if err != nil {
+		return WarriorData{}, fmt.Errorf("symbol scanner: %s", err)
+	}
+	if forSeen {
+		// for counts can use the predefined constants, unless they are
+		// redefined with EQU
+		for name, value := range predefinedConstants(config) {
+			if _, ok := symbols[name]; !ok {
+				symbols[name] = value
 			}
-			tokens = expandedTokens
-			// oops the embedded for loops are not implemented
-			// break
-		} else {
-			break
 		}
-		depth++
-		if depth > 12 {
-			return WarriorData{}, fmt.Errorf("for loop depth exceeded")
+
+		tokens, err = ForExpand(newBufTokenReader(tokens), symbols)
+		if err != nil {
+			return WarriorData{}, fmt.Errorf("for: %s", err)
 		}
 	}
 
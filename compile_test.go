@@ -75,6 +75,16 @@ func TestCompileWarriors88(t *testing.T) {
 	runWarriorTests(t, tests)
 }
 
+func TestCompilePSpace88(t *testing.T) {
+	for _, input := range []string{"ldp #0, 1\n", "stp #0, 1\n"} {
+		_, err := CompileWarrior(strings.NewReader(input), ConfigICWS88)
+		require.Error(t, err, input)
+
+		_, err = CompileWarrior(strings.NewReader(input), ConfigNOP94)
+		require.NoError(t, err, input)
+	}
+}
+
 func TestCompileWarriors94(t *testing.T) {
 	config := ConfigNOP94
 	tests := []warriorTestCase{
@@ -118,6 +128,16 @@ func TestCompileWarriorsFile94(t *testing.T) {
 			loadFilename: "test_files/paperhaze.rc",
 			config:       config,
 		},
+		{
+			filename:     "test_files/nestedfor.red",
+			loadFilename: "test_files/nestedfor.rc",
+			config:       config,
+		},
+		{
+			filename:     "test_files/nestedtable.red",
+			loadFilename: "test_files/nestedtable.rc",
+			config:       config,
+		},
 	}
 
 	runWarriorLoadFileTests(t, tests)
@@ -174,6 +194,250 @@ func TestCompileDoubleForLoop(t *testing.T) {
 	assert.Equal(t, 7, w.Start)
 }
 
+func TestCompileNestedForLabels(t *testing.T) {
+	config := ConfigNOP94
+
+	// each expansion of the inner loop has its own line label, and the
+	// inner count depends on the outer counter
+	input := `
+	a i for 2
+	b j for i
+	dat a, b
+	rof
+	rof
+`
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 7999, BMode: DIRECT, B: 0},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 7998, BMode: DIRECT, B: 7999},
+	}, w.Code)
+}
+
+func TestCompileNestedForConstants(t *testing.T) {
+	config := ConfigNOP94
+
+	// inner and outer counts can use symbols defined from the predefined
+	// constants
+	input := `
+	x equ CORESIZE/4000
+	y equ (x+1)
+	i for x
+	j for y*i
+	dat i, j
+	rof
+	rof
+`
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 1, BMode: DIRECT, B: 1},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 1, BMode: DIRECT, B: 2},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 1, BMode: DIRECT, B: 3},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 1},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 2},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 3},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 4},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 5},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2, BMode: DIRECT, B: 6},
+	}, w.Code)
+}
+
+func TestCompileManyForLoops(t *testing.T) {
+	config := ConfigNOP94
+
+	input := "n equ 4\n"
+	for i := 0; i < 20; i++ {
+		input += "i for n\ndat i, 0\nrof\n"
+	}
+	// symbols defined after a loop can be used in later loops
+	input += "m equ 2\nfor m\ndat 0, 0\nrof\n"
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	require.Len(t, w.Code, 82)
+	assert.Equal(t, Address(4), w.Code[79].A)
+}
+
+func TestCompileForConcat(t *testing.T) {
+	config := ConfigNOP94
+
+	// a table of pointers with a label for each entry, with the start label
+	// on the first entry
+	input := `
+	jmp p02
+	start i for 3
+	p&i dat start, i*100
+	rof
+	jmp p&i
+`
+
+	// there is no counter to join outside of a loop
+	_, err := CompileWarrior(strings.NewReader(input), config)
+	require.Error(t, err)
+
+	input = strings.Replace(input, "jmp p&i", "jmp p03", 1)
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: JMP, OpMode: B, AMode: DIRECT, A: 2, BMode: DIRECT, B: 0},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 100},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 7999, BMode: DIRECT, B: 200},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 7998, BMode: DIRECT, B: 300},
+		{Op: JMP, OpMode: B, AMode: DIRECT, A: 7999, BMode: DIRECT, B: 0},
+	}, w.Code)
+}
+
+func TestCompileRegisters(t *testing.T) {
+	config := ConfigNOP94
+
+	// registers keep their values across lines, and labels take precedence
+	// over registers with the same name
+	input := `
+	org (s=2)
+	dat (a=CORESIZE/3), a*2
+	dat a+1, b
+b	dat s, 0
+`
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2666, BMode: DIRECT, B: 5332},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 2667, BMode: DIRECT, B: 1},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+	}, w.Code)
+	assert.Equal(t, 2, w.Start)
+}
+
+func TestCompileUnassignedRegisters(t *testing.T) {
+	config := ConfigNOP94
+
+	// a letter is only a register if the warrior assigns it somewhere
+	for _, input := range []string{
+		"dat a, 0\n",
+		"i for 2\ndat i, 0\nrof\njmp i\n",
+		"for n\ndat 0, 0\nrof\n",
+		"dat (a=1), b\n",
+	} {
+		_, err := CompileWarrior(strings.NewReader(input), config)
+		require.Error(t, err, input)
+		assert.ErrorContains(t, err, "undefined", input)
+	}
+
+	// an assignment on a later line still makes the letter a register
+	w, err := CompileWarrior(strings.NewReader("dat a, 0\ndat (a=5), a\n"), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 5, BMode: DIRECT, B: 5},
+	}, w.Code)
+}
+
+func TestCompileConstants(t *testing.T) {
+	config := ConfigNOP94
+	config.Cycles = 5000
+	config.ReadLimit = 400
+	config.WriteLimit = 300
+	config.Warriors = 3
+	config.Rounds = 10
+
+	input := `
+;assert MAXCYCLES == 5000
+	dat MAXCYCLES, PSPACESIZE
+	dat READLIMIT, WRITELIMIT
+	dat WARRIORS, ROUNDS
+	dat VERSION, CURLINE
+x	equ CURLINE*2
+	dat CURLINE, x
+	end CURLINE-4
+`
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 5000, BMode: DIRECT, B: 500},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 400, BMode: DIRECT, B: 300},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 3, BMode: DIRECT, B: 10},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 94, BMode: DIRECT, B: 3},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 4, BMode: DIRECT, B: 8},
+	}, w.Code)
+	assert.Equal(t, 1, w.Start)
+
+	// warriors and rounds default to a single round between 2 warriors
+	w, err = CompileWarrior(strings.NewReader("dat WARRIORS, ROUNDS\n"), ConfigNOP94)
+	require.NoError(t, err)
+	assert.Equal(t, Address(2), w.Code[0].A)
+	assert.Equal(t, Address(1), w.Code[0].B)
+
+	_, err = CompileWarrior(strings.NewReader("CURLINE equ 1\ndat 0, 0\n"), ConfigNOP94)
+	require.Error(t, err)
+}
+
+func TestCompileCurlineStart(t *testing.T) {
+	config := ConfigNOP94
+
+	// CURLINE in end, org and assertions is the number of instructions
+	// before them
+	input := `
+;assert CURLINE == 0
+	dat 0, 0
+	dat 0, 0
+	org CURLINE
+;assert CURLINE == 2
+	dat 0, 0
+	jmp 0
+	end
+`
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, 2, w.Start)
+
+	w, err = CompileWarrior(strings.NewReader(strings.Replace(input, "end", "end CURLINE-1", 1)), config)
+	require.NoError(t, err)
+	assert.Equal(t, 3, w.Start)
+
+	_, err = CompileWarrior(strings.NewReader(strings.Replace(input, "CURLINE == 2", "CURLINE == 0", 1)), config)
+	require.Error(t, err)
+}
+
+func TestCompileForConstants(t *testing.T) {
+	config := ConfigNOP94
+	config.Length = 6
+
+	// fill the rest of the warrior up to MAXLENGTH
+	w, err := CompileWarrior(strings.NewReader(`
+	jmp 0
+	for MAXLENGTH-CURLINE
+	dat 0, CURLINE
+	rof
+`), config)
+	require.NoError(t, err)
+	require.Len(t, w.Code, 6)
+	for i, inst := range w.Code[1:] {
+		assert.Equal(t, Instruction{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: Address(i + 1)}, inst)
+	}
+
+	// CURLINE counts the instructions expanded by earlier loops
+	w, err = CompileWarrior(strings.NewReader(`
+	for CORESIZE/4000
+	dat 0, 1
+	rof
+	for 5-CURLINE
+	dat 0, 2
+	rof
+`), config)
+	require.NoError(t, err)
+	fields := make([]Address, 0)
+	for _, inst := range w.Code {
+		fields = append(fields, inst.B)
+	}
+	assert.Equal(t, []Address{1, 1, 2, 2, 2}, fields)
+}
+
 func TestAssertPositive(t *testing.T) {
 	config := ConfigNOP94
 
@@ -201,3 +465,25 @@ dat.f $123, $123
 	require.Error(t, err)
 	require.Equal(t, WarriorData{}, w)
 }
+
+func TestCompilePSpace(t *testing.T) {
+	config := ConfigNOP94
+
+	input := `
+	ldp #0, res
+	ldp 1, res
+	stp res, #1
+	stp.a #2, res
+res	dat 0, 0
+`
+
+	w, err := CompileWarrior(strings.NewReader(input), config)
+	require.NoError(t, err)
+	assert.Equal(t, []Instruction{
+		{Op: LDP, OpMode: AB, AMode: IMMEDIATE, A: 0, BMode: DIRECT, B: 4},
+		{Op: LDP, OpMode: B, AMode: DIRECT, A: 1, BMode: DIRECT, B: 3},
+		{Op: STP, OpMode: B, AMode: DIRECT, A: 2, BMode: IMMEDIATE, B: 1},
+		{Op: STP, OpMode: A, AMode: IMMEDIATE, A: 2, BMode: DIRECT, B: 1},
+		{Op: DAT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+	}, w.Code)
+}
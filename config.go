@@ -3,14 +3,21 @@ package gmars
 import "fmt"
 
 type SimulatorConfig struct {
-	Mode       SimulatorMode
-	CoreSize   Address
-	Processes  Address
-	Cycles     Address
-	ReadLimit  Address
-	WriteLimit Address
-	Length     Address
-	Distance   Address
+	Mode       SimulatorMode `json:"mode"`
+	CoreSize   Address       `json:"core_size"`
+	Processes  Address       `json:"processes"`
+	Cycles     Address       `json:"cycles"`
+	ReadLimit  Address       `json:"read_limit"`
+	WriteLimit Address       `json:"write_limit"`
+	Length     Address       `json:"length"`
+	Distance   Address       `json:"distance"`
+	PSpaceSize Address       `json:"pspace_size"`
+
+	// Warriors and Rounds are the number of warriors and rounds in the
+	// battle. They are only used by the assembler for the WARRIORS and
+	// ROUNDS constants, and default to 2 warriors and 1 round if not set.
+	Warriors int `json:"warriors,omitempty"`
+	Rounds   int `json:"rounds,omitempty"`
 }
 
 var (
@@ -23,6 +30,7 @@ var (
 		WriteLimit: 8000,
 		Length:     100,
 		Distance:   100,
+		PSpaceSize: 0,
 	}
 	ConfigICWS88 = SimulatorConfig{
 		Mode:       ICWS88,
@@ -33,6 +41,7 @@ var (
 		WriteLimit: 8000,
 		Length:     300,
 		Distance:   100,
+		PSpaceSize: 0,
 	}
 	ConfigNOP94 = SimulatorConfig{
 		Mode:       ICWS94,
@@ -43,6 +52,7 @@ var (
 		WriteLimit: 8000,
 		Length:     100,
 		Distance:   100,
+		PSpaceSize: 500,
 	}
 	ConfigNopTiny = SimulatorConfig{
 		Mode:       NOP94,
@@ -53,6 +63,7 @@ var (
 		WriteLimit: 800,
 		Length:     20,
 		Distance:   20,
+		PSpaceSize: 50,
 	}
 	ConfigNop256 = SimulatorConfig{
 		Mode:       NOP94,
@@ -63,6 +74,7 @@ var (
 		WriteLimit: 800,
 		Length:     10,
 		Distance:   10,
+		PSpaceSize: 16,
 	}
 	ConfigNopNano = SimulatorConfig{
 		Mode:       NOP94,
@@ -73,6 +85,7 @@ var (
 		WriteLimit: 80,
 		Length:     5,
 		Distance:   5,
+		PSpaceSize: 5,
 	}
 
 	configPresets = map[string]SimulatorConfig{
@@ -85,6 +98,42 @@ var (
 	}
 )
 
+// defaultPSpaceSize returns the pMARS default P-space size of 1/16th of the
+// core size, with a minimum of 1.
+func defaultPSpaceSize(coreSize Address) Address {
+	size := coreSize / 16
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// pspaceSize returns the configured P-space size, or the default size for the
+// core size if it is not set.
+func (c SimulatorConfig) pspaceSize() Address {
+	if c.PSpaceSize == 0 {
+		return defaultPSpaceSize(c.CoreSize)
+	}
+	return c.PSpaceSize
+}
+
+// warriorCount returns the configured number of warriors, or 2 if it is not
+// set.
+func (c SimulatorConfig) warriorCount() int {
+	if c.Warriors < 1 {
+		return 2
+	}
+	return c.Warriors
+}
+
+// roundCount returns the configured number of rounds, or 1 if it is not set.
+func (c SimulatorConfig) roundCount() int {
+	if c.Rounds < 1 {
+		return 1
+	}
+	return c.Rounds
+}
+
 func PresetConfig(name string) (SimulatorConfig, error) {
 	config, ok := configPresets[name]
 	if !ok {
@@ -103,6 +152,7 @@ func NewQuickConfig(mode SimulatorMode, coreSize, processes, cycles, length Addr
 		WriteLimit: coreSize,
 		Length:     length,
 		Distance:   length,
+		PSpaceSize: defaultPSpaceSize(coreSize),
 	}
 	return out
 }
@@ -135,5 +185,9 @@ func (c SimulatorConfig) Validate() error {
 		return fmt.Errorf("invalid distance")
 	}
 
+	if c.PSpaceSize > c.CoreSize {
+		return fmt.Errorf("invalid pspace size")
+	}
+
 	return nil
 }
@@ -2,8 +2,6 @@ package gmars
 
 import (
 	"fmt"
-	gtoken "go/token"
-	"go/types"
 	"strconv"
 )
 
@@ -32,6 +30,14 @@ func ExpandAndEvaluate(expr []token, symbols map[string][]token) (int, error) {
 		expanded = append(expanded, tok)
 	}
 
+	assigned := registerSet{}
+	assigned.add(expanded)
+	for _, tok := range expanded {
+		if tok.typ == tokText && !assigned[tok.val] {
+			return 0, fmt.Errorf("symbol '%s' undefined", tok.val)
+		}
+	}
+
 	return evaluateExpression(expanded)
 }
 
@@ -101,88 +107,301 @@ func expandExpressions(values map[string][]token, graph map[string][]string) (ma
 	return resolved, nil
 }
 
-func combineSigns(expr []token) []token {
-	out := make([]token, 0, len(expr))
-	lastOut := token{typ: tokEOF}
+// registers holds the values of the pMARS registers a to z, which can be
+// assigned in one expression and used in later expressions
+type registers [26]int
 
-	// please forgive me for this lol
-	for i := 0; i < len(expr); i++ {
-		if lastOut.typ == tokSymbol {
-			negativeFound := false
-			for ; i < len(expr); i++ {
-				if !(expr[i].val == "-" || expr[i].val == "+") {
-					break
-				}
-				if expr[i].val == "-" {
-					negativeFound = true
-				}
-			}
-			if negativeFound {
-				out = append(out, token{tokSymbol, "-"})
-			}
-			if i < len(expr) {
-				out = append(out, expr[i])
-				lastOut = expr[i]
-			}
-		} else {
-			if i < len(expr) {
-				out = append(out, expr[i])
-				lastOut = expr[i]
-			}
+// isRegister returns true if tok names a register
+func isRegister(tok token) bool {
+	return tok.typ == tokText && len(tok.val) == 1 && tok.val[0] >= 'a' && tok.val[0] <= 'z'
+}
+
+// registerSet holds the registers a warrior assigns with '='. Only these
+// letters are read as registers, so any other undefined letter, such as a
+// for counter used after its loop, is still an undefined symbol.
+type registerSet map[string]bool
+
+// add adds the registers assigned in expr to s
+func (s registerSet) add(expr []token) {
+	for i := 0; i+1 < len(expr); i++ {
+		if isRegister(expr[i]) && expr[i+1].typ == tokSymbol && expr[i+1].val == "=" {
+			s[expr[i].val] = true
 		}
 	}
-	return out
 }
 
-func flipDoubleNegatives(expr []token) []token {
-	out := make([]token, 0, len(expr))
-	for i := 0; i < len(expr); i++ {
-		if expr[i].val == "-" {
-			if i+1 < len(expr) && expr[i+1].val == "-" {
-				out = append(out, token{tokSymbol, "+"})
-				i += 1
-				continue
+// exprParser evaluates an expression with recursive descent, using the
+// pMARS operator precedence, from lowest to highest:
+//
+//	=
+//	||
+//	&&
+//	== != < > <= >=
+//	+ -
+//	* / %
+//	unary - + !
+//
+// Values are ints, comparisons and logical operators return 1 or 0, and
+// division truncates towards zero as in C.
+type exprParser struct {
+	expr      []token
+	pos       int
+	registers *registers
+}
+
+// evaluateExpression evaluates expr with all registers set to 0
+func evaluateExpression(expr []token) (int, error) {
+	return evaluateRegisters(expr, &registers{})
+}
+
+// evaluateRegisters evaluates expr, reading and assigning regs
+func evaluateRegisters(expr []token, regs *registers) (int, error) {
+	for i, tok := range expr {
+		if (tok.typ == tokText && !isRegister(tok)) || !tok.IsExpressionTerm() {
+			return 0, fmt.Errorf("unexpected token in expression: '%s' at position %d", tok, exprPosition(expr, i))
+		}
+	}
+
+	p := &exprParser{expr: expr, registers: regs}
+	val, err := p.parseAssign()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.expr) {
+		return 0, p.errorf(p.pos, "unexpected '%s'", p.expr[p.pos].val)
+	}
+	return val, nil
+}
+
+// exprPosition returns the 1-based position of token i in the expression
+// written without spaces
+func exprPosition(expr []token, i int) int {
+	pos := 1
+	for _, tok := range expr[:i] {
+		pos += len(tok.val)
+	}
+	return pos
+}
+
+func (p *exprParser) errorf(i int, format string, args ...any) error {
+	text := ""
+	for _, tok := range p.expr {
+		text += tok.val
+	}
+	return fmt.Errorf("%s at position %d in '%s'", fmt.Sprintf(format, args...), exprPosition(p.expr, i), text)
+}
+
+// accept consumes the next token and returns true if it is one of the
+// operators in ops
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	if p.pos >= len(p.expr) || p.expr[p.pos].typ != tokSymbol {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.expr[p.pos].val == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// parseAssign assigns a register and returns its value. Assignments are
+// right associative, so 'a=b=1' sets both registers.
+func (p *exprParser) parseAssign() (int, error) {
+	if p.pos+1 < len(p.expr) && isRegister(p.expr[p.pos]) {
+		next := p.expr[p.pos+1]
+		if next.typ == tokSymbol && next.val == "=" {
+			r := p.expr[p.pos].val[0] - 'a'
+			p.pos += 2
+			val, err := p.parseAssign()
+			if err != nil {
+				return 0, err
 			}
+			p.registers[r] = val
+			return val, nil
 		}
-		out = append(out, expr[i])
 	}
-	return out
+	return p.parseOr()
 }
 
-func evaluateExpression(expr []token) (int, error) {
-	for _, tok := range expr {
-		if tok.typ == tokText || !tok.IsExpressionTerm() {
-			return 0, fmt.Errorf("unexpected token in expression: '%s'", tok)
+func (p *exprParser) parseOr() (int, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return left, nil
 		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return 0, err
+		}
+		left = boolValue(left != 0 || right != 0)
 	}
+}
 
-	combinedExpr := combineSigns(expr)
-	flippedExpr := flipDoubleNegatives(combinedExpr)
+func (p *exprParser) parseAnd() (int, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseCompare()
+		if err != nil {
+			return 0, err
+		}
+		left = boolValue(left != 0 && right != 0)
+	}
+}
 
-	exprStr := ""
-	for _, tok := range flippedExpr {
-		exprStr += tok.val
+func (p *exprParser) parseCompare() (int, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op, ok := p.accept("==", "!=", "<", ">", "<=", ">=")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "==":
+			left = boolValue(left == right)
+		case "!=":
+			left = boolValue(left != right)
+		case "<":
+			left = boolValue(left < right)
+		case ">":
+			left = boolValue(left > right)
+		case "<=":
+			left = boolValue(left <= right)
+		case ">=":
+			left = boolValue(left >= right)
+		}
 	}
+}
 
-	fs := gtoken.NewFileSet()
-	tv, err := types.Eval(fs, nil, gtoken.NoPos, exprStr)
+func (p *exprParser) parseSum() (int, error) {
+	left, err := p.parseProduct()
 	if err != nil {
 		return 0, err
 	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
 
-	valStr := tv.Value.String()
-	if valStr == "true" {
-		return 1, nil
-	} else if valStr == "false" {
-		return 0, nil
+func (p *exprParser) parseProduct() (int, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		opPos := p.pos
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			left *= right
+		case "/":
+			if right == 0 {
+				return 0, p.errorf(opPos, "division by zero")
+			}
+			left /= right
+		case "%":
+			if right == 0 {
+				return 0, p.errorf(opPos, "modulo by zero")
+			}
+			left %= right
+		}
 	}
+}
 
-	val, err := strconv.ParseInt(valStr, 10, 32)
+func (p *exprParser) parseUnary() (int, error) {
+	op, ok := p.accept("-", "+", "!")
+	if !ok {
+		return p.parsePrimary()
+	}
+	val, err := p.parseUnary()
 	if err != nil {
 		return 0, err
 	}
+	switch op {
+	case "-":
+		return -val, nil
+	case "!":
+		return boolValue(val == 0), nil
+	default:
+		return val, nil
+	}
+}
 
-	return int(val), nil
+func (p *exprParser) parsePrimary() (int, error) {
+	if p.pos >= len(p.expr) {
+		return 0, p.errorf(p.pos, "unexpected end of expression")
+	}
+
+	tok := p.expr[p.pos]
+	switch tok.typ {
+	case tokNumber:
+		val, err := strconv.Atoi(tok.val)
+		if err != nil {
+			return 0, p.errorf(p.pos, "invalid number '%s'", tok.val)
+		}
+		p.pos++
+		return val, nil
+	case tokText:
+		p.pos++
+		return p.registers[tok.val[0]-'a'], nil
+	case tokParenL:
+		open := p.pos
+		p.pos++
+		val, err := p.parseAssign()
+		if err != nil {
+			return 0, err
+		}
+		if p.pos >= len(p.expr) || p.expr[p.pos].typ != tokParenR {
+			return 0, p.errorf(open, "unclosed '('")
+		}
+		p.pos++
+		return val, nil
+	default:
+		return 0, p.errorf(p.pos, "unexpected '%s'", tok.val)
+	}
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 func exprEqual(a, b []token) bool {
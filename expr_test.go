@@ -28,68 +28,6 @@ func TestExpandExpressions(t *testing.T) {
 	}, output)
 }
 
-func TestCombineSigns(t *testing.T) {
-	testCases := []struct {
-		input  string
-		output []token
-	}{
-		{
-			input: "1++-2",
-			output: []token{
-				{tokNumber, "1"},
-				{tokSymbol, "+"},
-				{tokSymbol, "-"},
-				{tokNumber, "2"},
-			},
-		},
-		{
-			input: "1-+-2",
-			output: []token{
-				{tokNumber, "1"},
-				{tokSymbol, "-"},
-				{tokSymbol, "-"},
-				{tokNumber, "2"},
-			},
-		},
-	}
-
-	for _, test := range testCases {
-		lexer := newLexer(strings.NewReader(test.input))
-		tokens, err := lexer.Tokens()
-		tokens = tokens[:len(tokens)-1]
-		require.NoError(t, err)
-
-		combinedTokens := combineSigns(tokens)
-		require.Equal(t, test.output, combinedTokens)
-	}
-}
-
-func TestFlipDoubleNegatives(t *testing.T) {
-	testCases := []struct {
-		input  string
-		output []token
-	}{
-		{
-			input: "1--1",
-			output: []token{
-				{tokNumber, "1"},
-				{tokSymbol, "+"},
-				{tokNumber, "1"},
-			},
-		},
-	}
-
-	for _, test := range testCases {
-		lexer := newLexer(strings.NewReader(test.input))
-		tokens, err := lexer.Tokens()
-		tokens = tokens[:len(tokens)-1]
-		require.NoError(t, err)
-
-		combinedTokens := flipDoubleNegatives(tokens)
-		require.Equal(t, test.output, combinedTokens)
-	}
-}
-
 func TestEvaluateExpressionPositive(t *testing.T) {
 	testCases := map[string]int{
 		"1":       1,
@@ -103,21 +41,44 @@ func TestEvaluateExpressionPositive(t *testing.T) {
 
 		// handle signs
 		"1 - -1": 2,
+		"1++-2":  -1,
+		"1-+-2":  3,
+		"1--1":   2,
+		"--1":    1,
+
+		// pMARS precedence and C division
+		"2+3*4-1":    13,
+		"-7/2":       -3,
+		"7%-3":       1,
+		"-7%3":       -1,
+		"10-4-3":     3,
+		"1+1==2":     1,
+		"1<2==1":     1,
+		"2*(3+4)%5":  4,
+		"!0":         1,
+		"!5":         0,
+		"!0+1":       2,
+		"-(2+3)":     -5,
+		"3 != 4":     1,
+		"3 != 3":     0,
+		"8000000000": 8000000000,
 
 		// logic
-		"1 > 2":        0,
-		"2 > 1":        1,
-		"1 < 2":        1,
-		"2 < 1":        0,
-		"1 >= 1":       1,
-		"2 <= 2":       1,
-		"8000 == 8000": 1,
-		"8000 == 800":  0,
-		// hmmm, these need to be fixed
-		// "1 && 1":           1,
-		// "1 && 0":           0,
-		// "1 || 1":           1,
-		// "1 || 0":           0,
+		"1 > 2":            0,
+		"2 > 1":            1,
+		"1 < 2":            1,
+		"2 < 1":            0,
+		"1 >= 1":           1,
+		"2 <= 2":           1,
+		"8000 == 8000":     1,
+		"8000 == 800":      0,
+		"1 && 1":           1,
+		"1 && 0":           0,
+		"1 || 1":           1,
+		"1 || 0":           1,
+		"0 || 0":           0,
+		"2 && 3":           1,
+		"1 || 0 && 0":      1,
 		"2 == 1 || 2 == 2": 1,
 		"2 == 1 || 2 == 3": 0,
 	}
@@ -132,7 +93,7 @@ func TestEvaluateExpressionPositive(t *testing.T) {
 
 		val, err := evaluateExpression(tokens)
 		require.NoError(t, err)
-		assert.Equal(t, expected, val)
+		assert.Equal(t, expected, val, input)
 	}
 }
 
@@ -141,6 +102,11 @@ func TestEvaluateExpressionNegative(t *testing.T) {
 		")21",
 		"2^3",
 		"2{2",
+		"(1+2",
+		"1+",
+		"1 2",
+		"!",
+		"99999999999999999999",
 	}
 
 	for _, input := range cases {
@@ -155,3 +121,60 @@ func TestEvaluateExpressionNegative(t *testing.T) {
 		assert.Equal(t, 0, val)
 	}
 }
+
+func TestEvaluateExpressionRegisters(t *testing.T) {
+	regs := &registers{}
+	testCases := []struct {
+		input    string
+		expected int
+	}{
+		{"(a=8000/3)", 2666},
+		{"a+1", 2667},
+		{"b=c=2", 2},
+		{"b*c", 4},
+		{"(z=a)==a", 1},
+		{"z", 2666},
+		{"d", 0},
+	}
+
+	for _, test := range testCases {
+		lexer := newLexer(strings.NewReader(test.input))
+		tokens, err := lexer.Tokens()
+		require.NoError(t, err)
+		tokens = tokens[:len(tokens)-1]
+
+		val, err := evaluateRegisters(tokens, regs)
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, val, test.input)
+	}
+
+	for _, input := range []string{"1=2", "ab=1", "A=1", "(a=)"} {
+		lexer := newLexer(strings.NewReader(input))
+		tokens, err := lexer.Tokens()
+		require.NoError(t, err)
+		tokens = tokens[:len(tokens)-1]
+
+		_, err = evaluateRegisters(tokens, regs)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestEvaluateExpressionErrors(t *testing.T) {
+	testCases := map[string]string{
+		"10/(2-2)": "division by zero at position 3 in '10/(2-2)'",
+		"1+7%0":    "modulo by zero at position 4 in '1+7%0'",
+		"(1+2":     "unclosed '(' at position 1 in '(1+2'",
+		"1+2)":     "unexpected ')' at position 4 in '1+2)'",
+	}
+
+	for input, expected := range testCases {
+		lexer := newLexer(strings.NewReader(input))
+		tokens, err := lexer.Tokens()
+		require.NoError(t, err)
+		tokens = tokens[:len(tokens)-1]
+
+		_, err = evaluateExpression(tokens)
+		require.Error(t, err)
+		assert.Equal(t, expected, err.Error())
+	}
+}
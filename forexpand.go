@@ -22,18 +22,35 @@ type forExpander struct {
 	forIndex             int
 	forContent           []token
 	forDepth             int
+	forNested            bool
+
+	// scope is prepended to the labels of loops nested in other loops, so
+	// each expansion of a nested loop has its own labels
+	scope string
+
+	// curLine is the number of instructions emitted so far, which is the
+	// value of CURLINE in for counts
+	curLine int
 
 	symbols map[string][]token
 
 	// output fields
 	tokens chan token
 	closed bool
+	done   bool
 }
 
 type forStateFn func(f *forExpander) forStateFn
 
 func newForExpander(lex tokenReader, symbols map[string][]token) *forExpander {
-	f := &forExpander{lex: lex, symbols: symbols}
+	return newScopedForExpander(lex, symbols, "", 0)
+}
+
+// newScopedForExpander creates a forExpander for the content of a nested
+// loop, whose labels are prefixed with scope and whose first instruction is
+// on line curLine
+func newScopedForExpander(lex tokenReader, symbols map[string][]token, scope string, curLine int) *forExpander {
+	f := &forExpander{lex: lex, symbols: symbols, scope: scope, curLine: curLine}
 	f.next()
 	f.tokens = make(chan token)
 	go f.run()
@@ -76,10 +93,25 @@ func (f *forExpander) run() {
 
 	// add an extra EOF in case we end without one
 	// we don't want to block on reading from the channel
-	f.tokens <- token{tokEOF, ""}
+	f.emit(token{tokEOF, ""})
 	f.closed = true
 }
 
+// emit sends tok to the token channel. Nothing is sent after an EOF or
+// error token, since the reader stops reading at the first one.
+func (f *forExpander) emit(tok token) {
+	if f.done {
+		return
+	}
+	f.tokens <- tok
+	if tok.typ == tokEOF || tok.typ == tokError {
+		f.done = true
+	}
+	if tok.typ == tokText && tok.IsOp() {
+		f.curLine++
+	}
+}
+
 func (f *forExpander) NextToken() (token, error) {
 	if f.closed {
 		return token{}, fmt.Errorf("no more tokens")
@@ -103,7 +135,7 @@ func (f *forExpander) Tokens() ([]token, error) {
 }
 
 func (f *forExpander) emitConsume(nextState forStateFn) forStateFn {
-	f.tokens <- f.nextToken
+	f.emit(f.nextToken)
 	f.next()
 	return nextState
 }
@@ -149,7 +181,7 @@ func forConsumeLabels(f *forExpander) forStateFn {
 		f.next()
 		return forConsumeLabels
 	} else {
-		f.tokens <- token{tokError, fmt.Sprintf("expected label, op, newlines, or comment, got '%s'", f.nextToken)}
+		f.emit(token{tokError, fmt.Sprintf("expected label, op, newlines, or comment, got '%s'", f.nextToken)})
 		return nil
 	}
 }
@@ -158,7 +190,7 @@ func forConsumeLabels(f *forExpander) forStateFn {
 // emits the current nextToken and returns forConsumeLine
 func forWriteLabelsEmitConsumeLine(f *forExpander) forStateFn {
 	for _, label := range f.labelBuf {
-		f.tokens <- token{tokText, label}
+		f.emit(token{tokText, label})
 	}
 	f.labelBuf = make([]string, 0)
 	return f.emitConsume(forConsumeEmitLine)
@@ -213,15 +245,22 @@ func forFor(f *forExpander) forStateFn {
 	expr := make([]token, 0, len(f.exprBuf))
 	for _, tok := range f.exprBuf {
 		if tok.typ == tokEOF || tok.typ == tokError {
-			f.tokens <- token{tokError, fmt.Sprintf("unexpected expression term: %s", tok)}
+			f.emit(token{tokError, fmt.Sprintf("unexpected expression term: %s", tok)})
 		}
 		expr = append(expr, tok)
 	}
 	f.exprBuf = expr
 
-	val, err := ExpandAndEvaluate(f.exprBuf, f.symbols)
+	// CURLINE is the line of the first instruction in the loop
+	symbols := make(map[string][]token, len(f.symbols)+1)
+	for name, value := range f.symbols {
+		symbols[name] = value
+	}
+	symbols["CURLINE"] = []token{{tokNumber, fmt.Sprintf("%d", f.curLine)}}
+
+	val, err := ExpandAndEvaluate(f.exprBuf, symbols)
 	if err != nil {
-		f.tokens <- token{tokError, fmt.Sprintf("%s", err)}
+		f.emit(token{tokError, fmt.Sprintf("%s", err)})
 		return nil
 	}
 
@@ -240,12 +279,14 @@ func forFor(f *forExpander) forStateFn {
 
 	f.forLineLabelsToWrite = make([]string, len(f.forLineLabels))
 	for i, label := range f.forLineLabels {
-		f.forLineLabelsToWrite[i] = fmt.Sprintf("__for_%s_%s", f.forCountLabel, label)
+		f.forLineLabelsToWrite[i] = f.forLabel(label)
 	}
 
 	f.forCount = val
 	f.forIndex = 0 // should not be necessary
 	f.forContent = make([]token, 0)
+	f.forDepth = 0
+	f.forNested = false
 	f.labelBuf = make([]string, 0)
 
 	return forInnerLine
@@ -272,6 +313,7 @@ func forInnerLabels(f *forExpander) forStateFn {
 			opLower := strings.ToLower(f.nextToken.val)
 			if opLower == "for" {
 				f.forDepth += 1
+				f.forNested = true
 				return forInnerEmitLabels
 			} else if opLower == "rof" {
 				if f.forDepth > 0 {
@@ -284,24 +326,36 @@ func forInnerLabels(f *forExpander) forStateFn {
 				return forInnerEmitLabels
 			}
 		} else if f.nextToken.IsOp() {
-			if f.forLineLabelsToWrite != nil {
-				for _, label := range f.forLineLabelsToWrite {
-					f.tokens <- token{tokText, label}
-				}
-				f.forLineLabelsToWrite = nil
-			}
+			f.writeForLineLabels()
 			return forInnerEmitLabels
 		} else {
 			f.labelBuf = append(f.labelBuf, f.nextToken.val)
 			f.next()
 			return forInnerLabels
 		}
+	case tokSymbol:
+		// a label joined with '&' starts an instruction line
+		if f.nextToken.val == "&" {
+			f.writeForLineLabels()
+		}
+		return forInnerEmitLabels
 	default:
 		// not expecting legal input here, but we will let the parser deal with it
 		return forInnerEmitLabels
 	}
 }
 
+// writeForLineLabels writes the labels on the for line before the first
+// instruction of the loop
+func (f *forExpander) writeForLineLabels() {
+	if f.forLineLabelsToWrite != nil {
+		for _, label := range f.forLineLabelsToWrite {
+			f.emit(token{tokText, label})
+		}
+		f.forLineLabelsToWrite = nil
+	}
+}
+
 func forInnerEmitLabels(f *forExpander) forStateFn {
 	for _, label := range f.labelBuf {
 		f.forContent = append(f.forContent, token{tokText, label})
@@ -312,7 +366,7 @@ func forInnerEmitLabels(f *forExpander) forStateFn {
 func forInnerEmitConsumeLine(f *forExpander) forStateFn {
 	switch f.nextToken.typ {
 	case tokError:
-		f.tokens <- f.nextToken
+		f.emit(f.nextToken)
 		return nil
 	case tokEOF:
 		return nil
@@ -327,48 +381,150 @@ func forInnerEmitConsumeLine(f *forExpander) forStateFn {
 	}
 }
 
+// forLabel returns the label written in place of a label on the for line
+func (f *forExpander) forLabel(label string) string {
+	return fmt.Sprintf("__for_%s%s_%s", f.scope, f.forCountLabel, label)
+}
+
+// forRof expands the loop content once for each count. Loops nested in the
+// content are expanded by a new expander for each count, after the counter
+// has been replaced, so nested counters can depend on the outer counter.
+// Input after the loop is then expanded from forLine.
 func forRof(f *forExpander) forStateFn {
-	for f.nextToken.typ != tokNewline {
-		if f.nextToken.typ == tokEOF || f.nextToken.typ == tokError {
-			f.tokens <- f.nextToken
+	for f.nextToken.typ != tokNewline && f.nextToken.typ != tokEOF {
+		if f.nextToken.typ == tokError {
+			f.emit(f.nextToken)
 			return nil
 		}
 		f.next()
 	}
-	f.next()
+	if f.nextToken.typ == tokNewline {
+		f.next()
+	}
 
 	for i := 1; i <= f.forCount; i++ {
-		for _, tok := range f.forContent {
-			if tok.typ == tokText {
-				if tok.val == f.forCountLabel {
-					f.tokens <- token{tokNumber, fmt.Sprintf("%d", i)}
-				} else {
-					found := false
-					for _, label := range f.forLineLabels {
-						forLabel := fmt.Sprintf("__for_%s_%s", f.forCountLabel, label)
-						if tok.val == label {
-							f.tokens <- token{tokText, forLabel}
-							found = true
-							break
-						}
-					}
-					if !found {
-						f.tokens <- tok
-					}
-				}
+		content := f.substitute(i)
+		if !f.forNested {
+			for _, tok := range content {
+				f.emit(tok)
+			}
+			continue
+		}
+
+		scope := fmt.Sprintf("%s%s%d_", f.scope, f.forCountLabel, i)
+		content = append(content, token{tokEOF, ""})
+		inner := newScopedForExpander(newBufTokenReader(content), f.symbols, scope, f.curLine)
+		tokens, err := inner.Tokens()
+		if err != nil {
+			f.emit(token{tokError, fmt.Sprintf("%s", err)})
+			return nil
+		}
+		for _, tok := range tokens {
+			if tok.typ == tokError {
+				f.emit(tok)
+				return nil
+			}
+			if tok.typ != tokEOF {
+				f.emit(tok)
+			}
+		}
+	}
+
+	f.labelBuf = make([]string, 0)
+	return forLine
+}
+
+// substitute returns the loop content with the counter replaced by i and
+// the labels on the for line replaced by their loop labels. A label
+// followed by '&' and the counter is joined with the counter padded to two
+// digits, like pMARS, so 'x&i' becomes 'x01'. Nested loops using the same
+// counter label shadow the counter, and the labels of nested for lines are
+// left for the nested expansion.
+func (f *forExpander) substitute(i int) []token {
+	out := make([]token, 0, len(f.forContent))
+	depth := 0
+	shadowDepth := 0
+
+	for start := 0; start < len(f.forContent); {
+		end := start
+		for end < len(f.forContent) && f.forContent[end].typ != tokNewline {
+			end++
+		}
+		if end < len(f.forContent) {
+			end++
+		}
+		line := f.forContent[start:end]
+		start = end
+
+		// find the first token after the labels
+		labels := 0
+		for labels < len(line) {
+			tok := line[labels]
+			if tok.typ == tokColon || (tok.typ == tokText && !tok.IsOp() && !tok.IsPseudoOp()) {
+				labels++
+				continue
+			}
+			break
+		}
+		keyword := ""
+		if labels < len(line) && line[labels].typ == tokText && line[labels].IsPseudoOp() {
+			keyword = strings.ToLower(line[labels].val)
+		}
+
+		replace := shadowDepth == 0
+		replaceFrom := 0
+		switch keyword {
+		case "for":
+			depth++
+			replaceFrom = labels
+			if shadowDepth == 0 && labels > 0 && line[labels-1].val == f.forCountLabel {
+				shadowDepth = depth
+			}
+		case "rof":
+			if shadowDepth == depth {
+				shadowDepth = 0
+			}
+			depth--
+		}
+
+		for j := 0; j < len(line); j++ {
+			tok := line[j]
+			if !replace || j < replaceFrom || tok.typ != tokText {
+				out = append(out, tok)
+				continue
+			}
+
+			// join any number of '&' counter suffixes onto the label
+			joined := tok.val
+			for j+2 < len(line) && f.isConcat(line[j+1], line[j+2]) {
+				joined += fmt.Sprintf("%02d", i)
+				j += 2
+			}
+			if joined != tok.val {
+				out = append(out, token{tokText, joined})
 			} else {
-				f.tokens <- tok
+				out = append(out, f.replaceLabel(tok, i))
 			}
 		}
 	}
+	return out
+}
 
-	return forEmitConsumeStream
+// isConcat returns true if amp and counter join the counter onto a label
+func (f *forExpander) isConcat(amp, counter token) bool {
+	return amp.typ == tokSymbol && amp.val == "&" && counter.typ == tokText && f.forCountLabel != "" && counter.val == f.forCountLabel
 }
 
-func forEmitConsumeStream(f *forExpander) forStateFn {
-	for f.nextToken.typ != tokEOF {
-		f.tokens <- f.nextToken
-		f.next()
+// replaceLabel replaces the counter with i and the labels on the for line
+// with their loop labels
+func (f *forExpander) replaceLabel(tok token, i int) token {
+	if f.forCountLabel != "" && tok.val == f.forCountLabel {
+		return token{tokNumber, fmt.Sprintf("%d", i)}
+	}
+	for _, label := range f.forLineLabels {
+		if tok.val == label {
+			return token{tokText, f.forLabel(label)}
+		}
 	}
-	return nil
+	return tok
 }
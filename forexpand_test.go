@@ -32,27 +32,25 @@ func TestForExpander(t *testing.T) {
 		{
 			input: "i for 2\nj for 2\ndat i, j\nrof\nrof\n",
 			output: []token{
-				{tokText, "j"},
-				{tokText, "for"},
-				{tokNumber, "2"},
-				{tokNewline, ""},
 				{tokText, "dat"},
 				{tokNumber, "1"},
 				{tokComma, ","},
-				{tokText, "j"},
-				{tokNewline, ""},
-				{tokText, "rof"},
+				{tokNumber, "1"},
 				{tokNewline, ""},
-				{tokText, "j"},
-				{tokText, "for"},
+				{tokText, "dat"},
+				{tokNumber, "1"},
+				{tokComma, ","},
 				{tokNumber, "2"},
 				{tokNewline, ""},
 				{tokText, "dat"},
 				{tokNumber, "2"},
 				{tokComma, ","},
-				{tokText, "j"},
+				{tokNumber, "1"},
 				{tokNewline, ""},
-				{tokText, "rof"},
+				{tokText, "dat"},
+				{tokNumber, "2"},
+				{tokComma, ","},
+				{tokNumber, "2"},
 				{tokNewline, ""},
 				{tokEOF, ""},
 			},
@@ -94,6 +92,55 @@ func TestForExpander(t *testing.T) {
 				{tokEOF, ""},
 			},
 		},
+		// an inner counter with the same label shadows the outer counter
+		{
+			input: "i for 2\ni for 1\ndat i\nrof\nrof\n",
+			output: []token{
+				{tokText, "dat"},
+				{tokNumber, "1"},
+				{tokNewline, ""},
+				{tokText, "dat"},
+				{tokNumber, "1"},
+				{tokNewline, ""},
+				{tokEOF, ""},
+			},
+		},
+		// labels joined with counters by '&'
+		{
+			input: "i for 2\nj for 2\nx&i&j dat y&j\nrof\nrof\n",
+			output: []token{
+				{tokText, "x0101"},
+				{tokText, "dat"},
+				{tokText, "y01"},
+				{tokNewline, ""},
+				{tokText, "x0102"},
+				{tokText, "dat"},
+				{tokText, "y02"},
+				{tokNewline, ""},
+				{tokText, "x0201"},
+				{tokText, "dat"},
+				{tokText, "y01"},
+				{tokNewline, ""},
+				{tokText, "x0202"},
+				{tokText, "dat"},
+				{tokText, "y02"},
+				{tokNewline, ""},
+				{tokEOF, ""},
+			},
+		},
+		// rof at the end of input without a newline
+		{
+			input: "i for 2\ndat i\nrof",
+			output: []token{
+				{tokText, "dat"},
+				{tokNumber, "1"},
+				{tokNewline, ""},
+				{tokText, "dat"},
+				{tokNumber, "2"},
+				{tokNewline, ""},
+				{tokEOF, ""},
+			},
+		},
 		// no for
 		{
 			input: "test equ 2\ndat 0, test\n",
@@ -0,0 +1,220 @@
+package gmars
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// HeatKind is a kind of core access counted by a HeatmapReporter
+type HeatKind uint8
+
+const (
+	HeatExecute HeatKind = iota
+	HeatWrite
+	HeatRead
+	heatKindCount
+)
+
+var heatKindNames = []string{
+	HeatExecute: "exec",
+	HeatWrite:   "write",
+	HeatRead:    "read",
+}
+
+func (k HeatKind) String() string {
+	if k < heatKindCount {
+		return heatKindNames[k]
+	}
+	return fmt.Sprintf("HeatKind(%d)", k)
+}
+
+// heatColors are the colors of each warrior in heatmaps, matching the
+// colors used by vmars where possible
+var heatColors = []color.RGBA{
+	{R: 0xff, G: 0xff, B: 0x00, A: 0xff},
+	{R: 0x00, G: 0xff, B: 0xff, A: 0xff},
+	{R: 0xff, G: 0x00, B: 0xff, A: 0xff},
+	{R: 0xff, G: 0x60, B: 0x00, A: 0xff},
+	{R: 0x60, G: 0xff, B: 0x60, A: 0xff},
+	{R: 0x80, G: 0x80, B: 0xff, A: 0xff},
+}
+
+// HeatmapReporter implements a Reporter which counts the executions, writes
+// and reads of each core address by each warrior. Reads include the operand
+// values used by each instruction and the pointers followed by indirect
+// operands, and increments and decrements are counted as writes. Counts are
+// kept across rounds until Clear is called.
+type HeatmapReporter struct {
+	coresize Address
+	counts   [][heatKindCount][]int
+}
+
+// NewHeatmapReporter creates a HeatmapReporter for sim
+func NewHeatmapReporter(sim RecorderSource) *HeatmapReporter {
+	return &HeatmapReporter{coresize: sim.CoreSize()}
+}
+
+// ReportMask returns the report types used by the reporter
+func (h *HeatmapReporter) ReportMask() ReportMask {
+	return NewReportMask(WarriorTaskPop, WarriorWrite, WarriorIncrement, WarriorDecrement, WarriorRead)
+}
+
+func (h *HeatmapReporter) Report(report Report) {
+	var kind HeatKind
+	switch report.Type {
+	case WarriorTaskPop:
+		kind = HeatExecute
+	case WarriorWrite, WarriorIncrement, WarriorDecrement:
+		kind = HeatWrite
+	case WarriorRead:
+		kind = HeatRead
+	default:
+		return
+	}
+	h.grow(report.WarriorIndex)
+	h.counts[report.WarriorIndex][kind][report.Address%h.coresize]++
+}
+
+// grow makes sure there are counts for warrior wi
+func (h *HeatmapReporter) grow(wi int) {
+	for len(h.counts) <= wi {
+		var counts [heatKindCount][]int
+		for kind := range counts {
+			counts[kind] = make([]int, h.coresize)
+		}
+		h.counts = append(h.counts, counts)
+	}
+}
+
+// Count returns the number of times warrior wi accessed address a
+func (h *HeatmapReporter) Count(wi int, kind HeatKind, a Address) int {
+	if wi >= len(h.counts) || kind >= heatKindCount {
+		return 0
+	}
+	return h.counts[wi][kind][a%h.coresize]
+}
+
+// Coverage returns the number of addresses accessed at least once by
+// warrior wi
+func (h *HeatmapReporter) Coverage(wi int, kind HeatKind) int {
+	if wi >= len(h.counts) || kind >= heatKindCount {
+		return 0
+	}
+	covered := 0
+	for _, count := range h.counts[wi][kind] {
+		if count > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// Clear sets every count back to zero
+func (h *HeatmapReporter) Clear() {
+	h.counts = nil
+}
+
+// Merge adds the counts of other to h. Both reporters must be for the same
+// core size.
+func (h *HeatmapReporter) Merge(other *HeatmapReporter) error {
+	if other.coresize != h.coresize {
+		return fmt.Errorf("core size %d does not match %d", other.coresize, h.coresize)
+	}
+	h.grow(len(other.counts) - 1)
+	for wi, counts := range other.counts {
+		for kind := range counts {
+			for a, count := range counts[kind] {
+				h.counts[wi][kind][a] += count
+			}
+		}
+	}
+	return nil
+}
+
+// Image renders the counts of one kind as a grid of cells, with columns
+// addresses in each row and each address drawn as a scale by scale square.
+// Each warrior is drawn in its own color, brighter for addresses accessed
+// more often on a logarithmic scale, and colors are added together where
+// warriors overlap.
+func (h *HeatmapReporter) Image(kind HeatKind, columns, scale int) (*image.RGBA, error) {
+	if kind >= heatKindCount {
+		return nil, fmt.Errorf("invalid heat kind %d", kind)
+	}
+	if columns < 1 || scale < 1 {
+		return nil, fmt.Errorf("invalid image size")
+	}
+
+	rows := (int(h.coresize) + columns - 1) / columns
+	img := image.NewRGBA(image.Rect(0, 0, columns*scale, rows*scale))
+
+	// scale the counts of every warrior to the same maximum so they can be
+	// compared
+	max := 0
+	for _, counts := range h.counts {
+		for _, count := range counts[kind] {
+			if count > max {
+				max = count
+			}
+		}
+	}
+	logMax := math.Log1p(float64(max))
+
+	for a := 0; a < int(h.coresize); a++ {
+		var r, g, b float64
+		for wi, counts := range h.counts {
+			count := counts[kind][a]
+			if count == 0 {
+				continue
+			}
+			// accessed addresses are always at least dimly visible
+			level := 0.25 + 0.75*math.Log1p(float64(count))/logMax
+			c := heatColors[wi%len(heatColors)]
+			r += level * float64(c.R)
+			g += level * float64(c.G)
+			b += level * float64(c.B)
+		}
+		cell := color.RGBA{R: clampByte(r), G: clampByte(g), B: clampByte(b), A: 0xff}
+
+		x := (a % columns) * scale
+		y := (a / columns) * scale
+		for dy := 0; dy < scale; dy++ {
+			for dx := 0; dx < scale; dx++ {
+				img.SetRGBA(x+dx, y+dy, cell)
+			}
+		}
+	}
+
+	// fill the end of the last row that is past the end of core
+	for a := int(h.coresize); a < rows*columns; a++ {
+		x := (a % columns) * scale
+		y := (a / columns) * scale
+		for dy := 0; dy < scale; dy++ {
+			for dx := 0; dx < scale; dx++ {
+				img.SetRGBA(x+dx, y+dy, color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff})
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// WritePNG renders the counts of one kind with Image and encodes them to w
+// as a PNG
+func (h *HeatmapReporter) WritePNG(w io.Writer, kind HeatKind, columns, scale int) error {
+	img, err := h.Image(kind, columns, scale)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+func clampByte(v float64) uint8 {
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,103 @@
+package gmars
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeatmapReporter(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	heat := NewHeatmapReporter(sim)
+	sim.AddReporter(heat)
+
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	scan := WarriorData{Code: []Instruction{
+		{Op: SNE, OpMode: I, AMode: DIRECT, A: 10, BMode: DIRECT, B: 20},
+		{Op: JMP, OpMode: B, AMode: DIRECT, A: -1 + 8000, BMode: DIRECT, B: 0},
+	}}
+	_, err = sim.AddWarrior(&imp)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&scan)
+	require.NoError(t, err)
+
+	for round := 0; round < 2; round++ {
+		if round > 0 {
+			sim.Reset()
+		}
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+		require.NoError(t, sim.SpawnWarrior(1, 4000))
+		for i := 0; i < 10; i++ {
+			sim.RunCycle()
+		}
+	}
+
+	// counts add up over both rounds
+	assert.Equal(t, 2, heat.Count(0, HeatExecute, 5))
+	assert.Equal(t, 2, heat.Count(0, HeatWrite, 10))
+	assert.Equal(t, 0, heat.Count(0, HeatWrite, 11))
+	assert.Equal(t, 10, heat.Coverage(0, HeatExecute))
+	assert.Equal(t, 10, heat.Count(1, HeatExecute, 4000))
+	assert.Equal(t, 10, heat.Count(1, HeatRead, 4010))
+	assert.Equal(t, 10, heat.Count(1, HeatRead, 4020))
+	assert.Equal(t, 2, heat.Coverage(1, HeatRead))
+	assert.Equal(t, 0, heat.Count(2, HeatRead, 0))
+
+	other := NewHeatmapReporter(sim)
+	require.NoError(t, other.Merge(heat))
+	require.NoError(t, other.Merge(heat))
+	assert.Equal(t, 20, other.Count(1, HeatExecute, 4000))
+
+	small, err := NewReportingSimulator(ConfigNopTiny)
+	require.NoError(t, err)
+	require.Error(t, other.Merge(NewHeatmapReporter(small)))
+
+	heat.Clear()
+	assert.Equal(t, 0, heat.Count(0, HeatExecute, 5))
+}
+
+func TestHeatmapImage(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNopNano)
+	require.NoError(t, err)
+	heat := NewHeatmapReporter(sim)
+	heat.Report(Report{Type: WarriorWrite, WarriorIndex: 0, Address: 1})
+	heat.Report(Report{Type: WarriorWrite, WarriorIndex: 0, Address: 2})
+	heat.Report(Report{Type: WarriorWrite, WarriorIndex: 0, Address: 2})
+	heat.Report(Report{Type: WarriorDecrement, WarriorIndex: 1, Address: 2})
+
+	img, err := heat.Image(HeatWrite, 30, 2)
+	require.NoError(t, err)
+
+	// 80 addresses in rows of 30 take 3 rows
+	assert.Equal(t, 60, img.Bounds().Dx())
+	assert.Equal(t, 6, img.Bounds().Dy())
+
+	assert.Equal(t, color.RGBA{A: 0xff}, img.RGBAAt(0, 0))
+	dim := img.RGBAAt(2, 0)
+	assert.Equal(t, dim, img.RGBAAt(3, 1))
+	assert.Equal(t, uint8(0), dim.B)
+	assert.Greater(t, dim.R, uint8(0))
+
+	// address 2 is written by both warriors
+	both := img.RGBAAt(4, 0)
+	assert.Equal(t, uint8(0xff), both.R)
+	assert.Greater(t, both.B, uint8(0))
+
+	_, err = heat.Image(HeatKind(10), 30, 2)
+	require.Error(t, err)
+	_, err = heat.Image(HeatWrite, 0, 2)
+	require.Error(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, heat.WritePNG(buf, HeatWrite, 30, 2))
+	decoded, err := png.Decode(buf)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	assert.Equal(t, "write", HeatWrite.String())
+}
@@ -0,0 +1,262 @@
+package gmars
+
+// cellChange holds the contents of a core address before it was changed,
+// along with its state in the attached StateRecorder
+type cellChange struct {
+	address Address
+	inst    Instruction
+	state   CoreState
+	color   int
+}
+
+// turnChange holds the process queue changes of a single warrior turn: the
+// address popped from the front of the queue and the number of addresses
+// pushed to the back
+type turnChange struct {
+	warrior    int
+	popped     Address
+	popLen     Address
+	pushes     Address
+	terminated bool
+}
+
+// pspaceChange holds a P-space value of a warrior before it was replaced by
+// STP
+type pspaceChange struct {
+	warrior int
+	index   Address
+	value   Address
+}
+
+// cycleDelta holds every change made to the simulator state in one cycle
+type cycleDelta struct {
+	cycle  int
+	cells  []cellChange
+	turns  []turnChange
+	pspace []pspaceChange
+}
+
+// HistoryRecorder implements a Reporter which records the changes made to
+// core and the process queues in each cycle, keeping the most recent cycles
+// in a ring buffer so the simulator can be stepped backwards.
+//
+// If a StateRecorder is given, the HistoryRecorder forwards every report to
+// it and also restores its state when stepping back. The StateRecorder should
+// not be added to the simulator separately.
+//
+// History is cleared when the simulator is reset.
+type HistoryRecorder struct {
+	sim    ReportingSimulator
+	rec    *StateRecorder
+	shadow []Instruction
+
+	deltas []cycleDelta
+	start  int
+	count  int
+
+	current *cycleDelta
+	turn    *turnChange
+}
+
+// NewHistoryRecorder creates a HistoryRecorder for sim which keeps up to
+// length cycles of history. rec may be nil.
+func NewHistoryRecorder(sim ReportingSimulator, rec *StateRecorder, length int) *HistoryRecorder {
+	if length < 1 {
+		length = 1
+	}
+
+	shadow := make([]Instruction, sim.CoreSize())
+	for i := range shadow {
+		shadow[i] = sim.GetMem(Address(i))
+	}
+
+	return &HistoryRecorder{
+		sim:    sim,
+		rec:    rec,
+		shadow: shadow,
+		deltas: make([]cycleDelta, length),
+	}
+}
+
+// Len returns the number of cycles that can be stepped back
+func (h *HistoryRecorder) Len() int {
+	if h.current != nil {
+		return h.count + 1
+	}
+	return h.count
+}
+
+// Clear removes all recorded history
+func (h *HistoryRecorder) Clear() {
+	h.start = 0
+	h.count = 0
+	h.current = nil
+	h.turn = nil
+}
+
+// ReportMask returns the report types used by the recorder and its
+// StateRecorder
+func (h *HistoryRecorder) ReportMask() ReportMask {
+	mask := NewReportMask(SimReset, CycleStart, CycleEnd, WarriorSpawn, WarriorTaskPop, WarriorTerminate, WarriorWrite, WarriorIncrement, WarriorDecrement, WarriorPSpaceWrite)
+	if h.rec != nil {
+		mask |= h.rec.ReportMask()
+	}
+	return mask
+}
+
+func (h *HistoryRecorder) Report(report Report) {
+	switch report.Type {
+	case SimReset:
+		h.Clear()
+		for i := range h.shadow {
+			h.shadow[i] = Instruction{}
+		}
+	case CycleStart:
+		h.endCycle()
+		h.current = h.nextDelta()
+		h.current.cycle = report.Cycle
+	case CycleEnd:
+		h.endCycle()
+	case WarriorSpawn:
+		w := h.sim.GetWarrior(report.WarriorIndex)
+		for i := Address(0); i < Address(w.Length()); i++ {
+			a := (report.Address + i) % Address(len(h.shadow))
+			h.shadow[a] = h.sim.GetMem(a)
+		}
+	case WarriorTaskPop:
+		h.endTurn()
+		if h.current != nil {
+			h.current.turns = append(h.current.turns, turnChange{
+				warrior: report.WarriorIndex,
+				popped:  report.Address,
+				popLen:  Address(report.QueueLen),
+			})
+			h.turn = &h.current.turns[len(h.current.turns)-1]
+		}
+		h.recordCell(report.Address)
+	case WarriorPSpaceWrite:
+		if h.current != nil {
+			h.current.pspace = append(h.current.pspace, pspaceChange{
+				warrior: report.WarriorIndex,
+				index:   report.Address,
+				value:   report.Before.B,
+			})
+		}
+	case WarriorTerminate:
+		if h.turn != nil && h.turn.warrior == report.WarriorIndex {
+			h.turn.terminated = true
+		}
+	case WarriorWrite, WarriorIncrement, WarriorDecrement, WarriorRead, WarriorTaskTerminate:
+		h.recordCell(report.Address)
+	}
+
+	if h.rec != nil {
+		h.rec.Report(report)
+	}
+}
+
+// recordCell saves the previous contents of address a in the current cycle
+// and updates the shadow core with the new contents
+func (h *HistoryRecorder) recordCell(a Address) {
+	if h.current != nil {
+		change := cellChange{address: a, inst: h.shadow[a], color: -1}
+		if h.rec != nil {
+			change.state, change.color = h.rec.GetMemState(a)
+		}
+		h.current.cells = append(h.current.cells, change)
+	}
+	h.shadow[a] = h.sim.GetMem(a)
+}
+
+// nextDelta returns the next slot in the ring buffer, dropping the oldest
+// cycle if it is full. Slices in the slot are reused.
+func (h *HistoryRecorder) nextDelta() *cycleDelta {
+	i := (h.start + h.count) % len(h.deltas)
+	if h.count == len(h.deltas) {
+		h.start = (h.start + 1) % len(h.deltas)
+		h.count--
+	}
+
+	delta := &h.deltas[i]
+	delta.cells = delta.cells[:0]
+	delta.turns = delta.turns[:0]
+	delta.pspace = delta.pspace[:0]
+	return delta
+}
+
+// endTurn counts the processes pushed by the warrior in the current turn
+func (h *HistoryRecorder) endTurn() {
+	if h.turn == nil {
+		return
+	}
+	w := h.sim.GetWarrior(h.turn.warrior)
+	h.turn.pushes = w.ThreadCount() - h.turn.popLen
+	h.turn = nil
+}
+
+// endCycle adds the current cycle to the history. A cycle may end without a
+// CycleEnd report when a battle is decided, so this is also called before
+// starting a new cycle and before stepping back.
+func (h *HistoryRecorder) endCycle() {
+	if h.current == nil {
+		return
+	}
+	h.endTurn()
+	h.current = nil
+	h.count++
+}
+
+// StepBack returns the simulator to the state it was in n cycles ago, or as
+// far back as the history goes, and returns the number of cycles stepped. If
+// the simulator can not be restored, the error is returned and the history
+// of the stepped cycles is lost.
+func (h *HistoryRecorder) StepBack(n int) (int, error) {
+	h.endCycle()
+	if n > h.count {
+		n = h.count
+	}
+	if n < 1 {
+		return 0, nil
+	}
+
+	snapshot := h.sim.Snapshot()
+	for step := 0; step < n; step++ {
+		delta := &h.deltas[(h.start+h.count-1)%len(h.deltas)]
+		h.count--
+
+		for i := len(delta.cells) - 1; i >= 0; i-- {
+			change := delta.cells[i]
+			snapshot.Mem[change.address] = change.inst
+			h.shadow[change.address] = change.inst
+			if h.rec != nil {
+				h.rec.state[change.address] = change.state
+				h.rec.color[change.address] = change.color
+			}
+		}
+
+		for i := len(delta.turns) - 1; i >= 0; i-- {
+			turn := delta.turns[i]
+			ws := &snapshot.Warriors[turn.warrior]
+			queue := ws.Queue[:Address(len(ws.Queue))-turn.pushes]
+			ws.Queue = append([]Address{turn.popped}, queue...)
+			if turn.terminated {
+				ws.State = WarriorAlive
+			}
+		}
+
+		for i := len(delta.pspace) - 1; i >= 0; i-- {
+			change := delta.pspace[i]
+			snapshot.Warriors[change.warrior].PSpace[change.index] = change.value
+		}
+
+		snapshot.CycleCount = delta.cycle
+		snapshot.WarriorIndex = 0
+		snapshot.InCycle = false
+	}
+
+	err := h.sim.Restore(snapshot)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,147 @@
+package gmars
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHistorySim(t *testing.T, config SimulatorConfig, files []string, offsets []Address) ReportingSimulator {
+	sim, err := NewReportingSimulator(config)
+	require.NoError(t, err)
+
+	for i, name := range files {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, config)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&data)
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(i, offsets[i]))
+	}
+	return sim
+}
+
+func recorderDump(rec *StateRecorder) ([]CoreState, []int) {
+	states := make([]CoreState, len(rec.state))
+	colors := make([]int, len(rec.color))
+	copy(states, rec.state)
+	copy(colors, rec.color)
+	return states, colors
+}
+
+func TestHistoryStepBack(t *testing.T) {
+	files := []string{"warriors/94/simpleshot.red", "warriors/94/paperhaze.red"}
+	sim := newHistorySim(t, ConfigNOP94, files, []Address{0, 4000})
+	rec := NewStateRecorder(sim)
+	history := NewHistoryRecorder(sim, rec, 1000)
+	sim.AddReporter(history)
+
+	snapshots := make([]*Snapshot, 0)
+	states := make([][]CoreState, 0)
+	colors := make([][]int, 0)
+	for sim.WarriorLivingCount() > 1 && sim.CycleCount() < sim.MaxCycles() {
+		snapshots = append(snapshots, sim.Snapshot())
+		s, c := recorderDump(rec)
+		states = append(states, s)
+		colors = append(colors, c)
+		sim.RunCycle()
+	}
+	require.Greater(t, len(snapshots), 1)
+	require.Less(t, len(snapshots), 1000)
+	assert.Equal(t, len(snapshots), history.Len())
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		require.Equal(t, 1, stepBack(t, history, 1))
+		require.Equal(t, snapshots[i], sim.Snapshot(), "cycle %d", i)
+		s, c := recorderDump(rec)
+		require.Equal(t, states[i], s, "cycle %d", i)
+		require.Equal(t, colors[i], c, "cycle %d", i)
+	}
+	assert.Equal(t, 0, stepBack(t, history, 1))
+
+	// running forward again after stepping back gives the same result
+	for i := 0; i < 10; i++ {
+		sim.RunCycle()
+	}
+	assert.Equal(t, 10, stepBack(t, history, 20))
+	assert.Equal(t, snapshots[0], sim.Snapshot())
+}
+
+func TestHistoryProcesses(t *testing.T) {
+	// paper and a dwarf with many processes and a small process limit, so
+	// pushes are dropped when the queue is full
+	config := ConfigNOP94
+	config.Processes = 64
+	files := []string{"warriors/94/paperhaze.red", "warriors/94/bombspiral.red"}
+	sim := newHistorySim(t, config, files, []Address{0, 3000})
+	history := NewHistoryRecorder(sim, nil, 100000)
+	sim.AddReporter(history)
+
+	snapshots := make([]*Snapshot, 0)
+	for i := 0; i < 2000 && sim.WarriorLivingCount() > 1; i++ {
+		snapshots = append(snapshots, sim.Snapshot())
+		sim.RunCycle()
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i -= 100 {
+		stepBack(t, history, 100)
+		j := i - 99
+		if j < 0 {
+			j = 0
+		}
+		require.Equal(t, snapshots[j], sim.Snapshot(), "cycle %d", j)
+	}
+}
+
+func TestHistoryPSpace(t *testing.T) {
+	config := ConfigNopNano
+	data, err := CompileWarrior(strings.NewReader("stp.ab #5, #1\nstp.ab #6, #1\njmp $0\n"), config)
+	require.NoError(t, err)
+
+	sim, err := NewReportingSimulator(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&data)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	history := NewHistoryRecorder(sim, nil, 10)
+	sim.AddReporter(history)
+
+	before := sim.Snapshot()
+	sim.RunCycle()
+	sim.RunCycle()
+	assert.Equal(t, Address(6), sim.Snapshot().Warriors[0].PSpace[1])
+
+	assert.Equal(t, 1, stepBack(t, history, 1))
+	assert.Equal(t, Address(5), sim.Snapshot().Warriors[0].PSpace[1])
+	assert.Equal(t, 1, stepBack(t, history, 1))
+	assert.Equal(t, before, sim.Snapshot())
+}
+
+func TestHistoryLength(t *testing.T) {
+	files := []string{"warriors/94/imp.red"}
+	sim := newHistorySim(t, ConfigNOP94, files, []Address{0})
+	history := NewHistoryRecorder(sim, nil, 5)
+	sim.AddReporter(history)
+
+	for i := 0; i < 10; i++ {
+		sim.RunCycle()
+	}
+	assert.Equal(t, 5, history.Len())
+	assert.Equal(t, 5, stepBack(t, history, 10))
+	assert.Equal(t, 5, sim.CycleCount())
+
+	sim.Reset()
+	assert.Equal(t, 0, history.Len())
+}
+
+// stepBack steps history back n cycles and returns the number stepped
+func stepBack(t *testing.T, history *HistoryRecorder, n int) int {
+	stepped, err := history.StepBack(n)
+	require.NoError(t, err)
+	return stepped
+}
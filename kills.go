@@ -0,0 +1,114 @@
+package gmars
+
+// NoKiller is the killer of a process terminated by an instruction that no
+// warrior loaded or wrote, such as the empty core
+const NoKiller = -1
+
+// KillEvent is a WarriorTaskTerminate or WarriorTerminate report with the
+// warrior that last wrote the instruction which ended the process. Loading a
+// warrior counts as writing its code, and increments and decrements count as
+// writes.
+type KillEvent struct {
+	Report
+	Killer int
+}
+
+// KillSummary counts the processes and deaths of a warrior by the warrior
+// that wrote the instruction that killed them, with NoKiller for
+// instructions no warrior wrote
+type KillSummary struct {
+	Processes map[int]int
+	Deaths    map[int]int
+}
+
+// SummarizeKills sums kill events by the warrior that was killed
+func SummarizeKills(events []KillEvent) []KillSummary {
+	summaries := make([]KillSummary, 0)
+	for _, event := range events {
+		for len(summaries) <= event.WarriorIndex {
+			summaries = append(summaries, KillSummary{
+				Processes: make(map[int]int),
+				Deaths:    make(map[int]int),
+			})
+		}
+		summary := summaries[event.WarriorIndex]
+		if event.Type == WarriorTerminate {
+			summary.Deaths[event.Killer]++
+		} else {
+			summary.Processes[event.Killer]++
+		}
+	}
+	return summaries
+}
+
+// KillReporter implements a Reporter which tracks the last warrior to write
+// each core address and records a KillEvent for every process and warrior
+// termination. The killer of a warrior is the killer of its last process.
+type KillReporter struct {
+	sim     RecorderSource
+	writers []int
+	last    []int
+	events  []KillEvent
+}
+
+// NewKillReporter creates a KillReporter for sim
+func NewKillReporter(sim RecorderSource) *KillReporter {
+	r := &KillReporter{
+		sim:     sim,
+		writers: make([]int, sim.CoreSize()),
+	}
+	r.reset()
+	return r
+}
+
+// ReportMask returns the report types used by the reporter
+func (r *KillReporter) ReportMask() ReportMask {
+	return NewReportMask(SimReset, WarriorSpawn, WarriorWrite, WarriorIncrement, WarriorDecrement, WarriorTaskTerminate, WarriorTerminate)
+}
+
+// Events returns the kill events of every round in the order they happened
+func (r *KillReporter) Events() []KillEvent {
+	return r.events
+}
+
+// Summary returns the kills of each warrior summed over every round
+func (r *KillReporter) Summary() []KillSummary {
+	return SummarizeKills(r.events)
+}
+
+func (r *KillReporter) reset() {
+	for i := range r.writers {
+		r.writers[i] = NoKiller
+	}
+}
+
+func (r *KillReporter) Report(report Report) {
+	coresize := Address(len(r.writers))
+
+	switch report.Type {
+	case SimReset:
+		r.reset()
+	case WarriorSpawn:
+		w := r.sim.GetWarrior(report.WarriorIndex)
+		for i := report.Address; i < report.Address+Address(w.Length()); i++ {
+			r.writers[i%coresize] = report.WarriorIndex
+		}
+		for len(r.last) <= report.WarriorIndex {
+			r.last = append(r.last, NoKiller)
+		}
+	case WarriorWrite, WarriorIncrement, WarriorDecrement:
+		r.writers[report.Address%coresize] = report.WarriorIndex
+	case WarriorTaskTerminate:
+		killer := r.writers[report.Address%coresize]
+		if report.WarriorIndex < len(r.last) {
+			r.last[report.WarriorIndex] = killer
+		}
+		r.events = append(r.events, KillEvent{Report: report, Killer: killer})
+	case WarriorTerminate:
+		killer := NoKiller
+		if report.WarriorIndex < len(r.last) {
+			killer = r.last[report.WarriorIndex]
+		}
+		r.events = append(r.events, KillEvent{Report: report, Killer: killer})
+	}
+}
@@ -0,0 +1,94 @@
+package gmars
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillReporter(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	kills := NewKillReporter(sim)
+	sim.AddReporter(kills)
+
+	code := []string{
+		// bombs the second instruction of w1
+		"mov.i $2, $101\njmp $-1\ndat $0, $0\n",
+		"nop $0\nnop $0\n",
+		// killed by its own code
+		"dat $0, $0\n",
+		// killed by the empty core
+		"jmp $5\n",
+	}
+	for wi, c := range code {
+		data, err := CompileWarrior(strings.NewReader(c), ConfigNOP94)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&data)
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(wi, Address(wi*100)))
+	}
+	for i := 0; i < 3; i++ {
+		sim.RunCycle()
+	}
+
+	type kill struct {
+		Type    ReportType
+		Cycle   int
+		Warrior int
+		Address Address
+		Killer  int
+	}
+	events := make([]kill, 0)
+	for _, event := range kills.Events() {
+		events = append(events, kill{event.Type, event.Cycle, event.WarriorIndex, event.Address, event.Killer})
+	}
+	assert.Equal(t, []kill{
+		{WarriorTaskTerminate, 0, 2, 200, 2},
+		{WarriorTerminate, 0, 2, 200, 2},
+		{WarriorTaskTerminate, 1, 1, 101, 0},
+		{WarriorTerminate, 1, 1, 101, 0},
+		{WarriorTaskTerminate, 1, 3, 305, NoKiller},
+		{WarriorTerminate, 1, 3, 305, NoKiller},
+	}, events)
+
+	summary := kills.Summary()
+	require.Len(t, summary, 4)
+	assert.Empty(t, summary[0].Processes)
+	assert.Equal(t, map[int]int{0: 1}, summary[1].Processes)
+	assert.Equal(t, map[int]int{0: 1}, summary[1].Deaths)
+	assert.Equal(t, map[int]int{2: 1}, summary[2].Deaths)
+	assert.Equal(t, map[int]int{NoKiller: 1}, summary[3].Deaths)
+}
+
+func TestKillReporterReset(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	kills := NewKillReporter(sim)
+	sim.AddReporter(kills)
+
+	for _, c := range []string{"mov.i $1, $1\n", "jmp $1\n"} {
+		data, err := CompileWarrior(strings.NewReader(c), ConfigNOP94)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&data)
+		require.NoError(t, err)
+	}
+
+	// w0 writes to cell 1 in the first round, which is empty again when w1
+	// jumps to it in the next round
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	sim.RunCycle()
+	sim.Reset()
+	require.NoError(t, sim.SpawnWarrior(1, 0))
+	sim.RunCycle()
+	sim.RunCycle()
+
+	events := kills.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, 1, events[0].WarriorIndex)
+	assert.Equal(t, Address(1), events[0].Address)
+	assert.Equal(t, NoKiller, events[0].Killer)
+	assert.Empty(t, SummarizeKills(nil))
+}
@@ -173,6 +173,8 @@ func lexInput(l *lexer) lexStateFn {
 		return l.consume(lexPipe)
 	case '&':
 		return l.consume(lexAnd)
+	case '!':
+		return l.consume(lexBang)
 	case '\x1a':
 		return l.consume(lexInput)
 	default:
@@ -259,12 +261,13 @@ func lexComment(l *lexer) lexStateFn {
 	return lexInput
 }
 
+// lexEquals emits '==', or a single '=' used to assign registers
 func lexEquals(l *lexer) lexStateFn {
 	if l.nextRune == '=' {
 		return l.emitConsume(token{tokSymbol, "=="}, lexInput)
 	} else {
-		l.tokens <- token{tokError, fmt.Sprintf("expected '=' after '=', got '%s'", string(l.nextRune))}
-		return nil
+		l.tokens <- token{tokSymbol, "="}
+		return lexInput
 	}
 }
 
@@ -277,12 +280,14 @@ func lexPipe(l *lexer) lexStateFn {
 	}
 }
 
+// lexAnd emits '&&', or a single '&' used to concatenate labels with for
+// loop counters
 func lexAnd(l *lexer) lexStateFn {
 	if l.nextRune == '&' {
 		return l.emitConsume(token{tokSymbol, "&&"}, lexInput)
 	} else {
-		l.tokens <- token{tokError, fmt.Sprintf("expected '&' after '&', got '%s'", string(l.nextRune))}
-		return nil
+		l.tokens <- token{tokSymbol, "&"}
+		return lexInput
 	}
 }
 
@@ -295,6 +300,15 @@ func lexGt(l *lexer) lexStateFn {
 	}
 }
 
+func lexBang(l *lexer) lexStateFn {
+	if l.nextRune == '=' {
+		return l.emitConsume(token{tokSymbol, "!="}, lexInput)
+	} else {
+		l.tokens <- token{tokSymbol, "!"}
+		return lexInput
+	}
+}
+
 func lexLt(l *lexer) lexStateFn {
 	if l.nextRune == '=' {
 		return l.emitConsume(token{tokSymbol, "<="}, lexInput)
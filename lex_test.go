@@ -164,6 +164,30 @@ func TestLexer(t *testing.T) {
 				{tokEOF, ""},
 			},
 		},
+		{
+			input: "(a=1)==1\n",
+			expected: []token{
+				{tokParenL, "("},
+				{tokText, "a"},
+				{tokSymbol, "="},
+				{tokNumber, "1"},
+				{tokParenR, ")"},
+				{tokSymbol, "=="},
+				{tokNumber, "1"},
+				{tokNewline, ""},
+				{tokEOF, ""},
+			},
+		},
+		{
+			input: "x&i\n",
+			expected: []token{
+				{tokText, "x"},
+				{tokSymbol, "&"},
+				{tokText, "i"},
+				{tokNewline, ""},
+				{tokEOF, ""},
+			},
+		},
 	}
 
 	runLexTests(t, "TestLexer", testCases)
@@ -171,7 +195,7 @@ func TestLexer(t *testing.T) {
 
 func TestLexNegative(t *testing.T) {
 	inputs := []string{
-		"1 =! 0",
+		"1 |! 0",
 	}
 
 	for _, input := range inputs {
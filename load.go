@@ -154,6 +154,15 @@ func getOpMode94(Op OpCode, AMode AddressMode, BMode AddressMode) (OpMode, error
 			return I, nil
 		}
 
+	case LDP:
+		fallthrough
+	case STP:
+		if AMode == IMMEDIATE {
+			return AB, nil
+		} else {
+			return B, nil
+		}
+
 	case SLT:
 		if AMode == IMMEDIATE {
 			return AB, nil
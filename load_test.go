@@ -86,6 +86,8 @@ func TestValidInput94(t *testing.T) {
 	// random inputs that are valid but not worth validating output
 	cases := []string{
 		"ADD.BA $ 1, $ 1\n",
+		"LDP.AB # 0, $ 1\n",
+		"STP.B $ 1, # 2\n",
 	}
 
 	config := ConfigNOP94
@@ -121,6 +123,9 @@ func TestInvalidInput(t *testing.T) {
 		"JMZ # 0, $ 0\n",
 		"DJN # 0, $ 0\n",
 		"SPL # 0, $ 0\n",
+		// p-space is not part of the 88 standard
+		"LDP $ 0, $ 1\n",
+		"STP $ 0, $ 1\n",
 		"MOV $ 0, $ 1\nEND 2 ; BAD END ADDRESS\n",
 		"MOV $ 0, $ 1\nEND -2 ; BAD END ADDRESS\n",
 		// invalid addresses and modes
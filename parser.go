@@ -72,7 +72,14 @@ func (p *parser) loadPredefinedSymbols() {
 	p.symbols["MAXLENGTH"] = -1
 	p.symbols["MAXPROCESSES"] = -1
 	p.symbols["MINDISTANCE"] = -1
-	// p.symbols["CURLINE"] = -1
+	p.symbols["MAXCYCLES"] = -1
+	p.symbols["PSPACESIZE"] = -1
+	p.symbols["READLIMIT"] = -1
+	p.symbols["WRITELIMIT"] = -1
+	p.symbols["WARRIORS"] = -1
+	p.symbols["ROUNDS"] = -1
+	p.symbols["VERSION"] = -1
+	p.symbols["CURLINE"] = -1
 }
 
 // parse runs the state machine. the main flows are:
@@ -107,15 +114,26 @@ func (p *parser) parse() ([]sourceLine, WarriorData, error) {
 }
 
 func (p *parser) validateSymbols() error {
+	assigned := lineRegisters(p.lines)
 	for symbol, i := range p.references {
 		_, ok := p.symbols[symbol]
-		if !ok {
+		if !ok && !assigned[symbol] {
 			return fmt.Errorf("line %d: symbol '%s' undefined", i, symbol)
 		}
 	}
 	return nil
 }
 
+// lineRegisters returns the registers assigned in the operands of lines
+func lineRegisters(lines []sourceLine) registerSet {
+	assigned := registerSet{}
+	for _, line := range lines {
+		assigned.add(line.a)
+		assigned.add(line.b)
+	}
+	return assigned
+}
+
 func (p *parser) next() token {
 	if p.atEOF {
 		return token{typ: tokEOF}
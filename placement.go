@@ -0,0 +1,187 @@
+package gmars
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	placementTries    = 1000
+	placementRestarts = 100
+)
+
+// Placement holds the start offset of each warrior in a round and the index
+// of the warrior that runs first in each cycle.
+type Placement struct {
+	Offsets []Address
+	First   int
+}
+
+// PlacementFunc returns the Placement of the warriors for a round
+type PlacementFunc func(round int) (Placement, error)
+
+// PlaceWarriors returns random start offsets for n warriors that do not
+// overlap. The first warrior is always placed at offset 0 and the others are
+// placed between Length+Distance and CoreSize-Length-Distance, with at least
+// Length+Distance between the start of every pair of warriors in either
+// direction around the core, as in pMARS.
+func PlaceWarriors(config SimulatorConfig, n int, rng *rand.Rand) ([]Address, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("at least one warrior is required")
+	}
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]Address, n)
+	if n == 1 {
+		return offsets, nil
+	}
+
+	separation := config.Length + config.Distance
+	if Address(n)*separation > config.CoreSize {
+		return nil, fmt.Errorf("unable to fit %d warriors in core", n)
+	}
+	minStart := separation
+	maxStart := config.CoreSize - separation
+	startRange := int(maxStart-minStart) + 1
+
+	for restart := 0; restart < placementRestarts; restart++ {
+		placed := 1
+		for tries := 0; placed < n && tries < placementTries; tries++ {
+			offset := Address(rng.Intn(startRange)) + minStart
+			if offsetFits(offset, offsets[:placed], separation, config.CoreSize) {
+				offsets[placed] = offset
+				placed++
+			}
+		}
+		if placed == n {
+			return offsets, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to place %d warriors", n)
+}
+
+// offsetFits returns true if offset is at least separation away from each of
+// the existing offsets, measured in the shorter direction around the core
+func offsetFits(offset Address, existing []Address, separation, coreSize Address) bool {
+	for _, other := range existing {
+		diff := (offset + coreSize - other) % coreSize
+		if diff < separation || coreSize-diff < separation {
+			return false
+		}
+	}
+	return true
+}
+
+// SeededPlacement returns a PlacementFunc that places n warriors with
+// PlaceWarriors, using a random source that depends only on seed and the
+// round number. Any round of a battle can be reproduced from its seed. The
+// warrior running first rotates each round, as in pMARS.
+func SeededPlacement(config SimulatorConfig, n int, seed int64) PlacementFunc {
+	return func(round int) (Placement, error) {
+		rng := rand.New(rand.NewSource(mixSeed(seed, int64(round))))
+		offsets, err := PlaceWarriors(config, n, rng)
+		if err != nil {
+			return Placement{}, err
+		}
+		return Placement{Offsets: offsets, First: round % n}, nil
+	}
+}
+
+// FixedPlacement returns a PlacementFunc that places warriors with placement
+// and then moves warrior 1 to offset, as with the pMARS -F flag
+func FixedPlacement(placement PlacementFunc, offset Address) PlacementFunc {
+	return func(round int) (Placement, error) {
+		p, err := placement(round)
+		if err != nil {
+			return Placement{}, err
+		}
+		if len(p.Offsets) < 2 {
+			return Placement{}, fmt.Errorf("fixed position needs at least 2 warriors")
+		}
+		p.Offsets[1] = offset
+		return p, nil
+	}
+}
+
+// PermutationPlacement returns a PlacementFunc for two warriors that tries
+// every start offset of the second warrior between Length+Distance and
+// CoreSize-Length-Distance, the same range as PlaceWarriors and pMARS, in an
+// order shuffled by seed. Each offset is used for two
+// rounds in a row, first with warrior 0 running first and then with warrior 1
+// running first, so all permutations are covered every PermutationCount
+// rounds.
+func PermutationPlacement(config SimulatorConfig, seed int64) (PlacementFunc, error) {
+	count, err := PermutationCount(config)
+	if err != nil {
+		return nil, err
+	}
+
+	minStart := config.Length + config.Distance
+	order := rand.New(rand.NewSource(seed)).Perm(count / 2)
+
+	return func(round int) (Placement, error) {
+		if round < 0 {
+			return Placement{}, fmt.Errorf("invalid round %d", round)
+		}
+		round %= count
+		return Placement{
+			Offsets: []Address{0, minStart + Address(order[round/2])},
+			First:   round % 2,
+		}, nil
+	}, nil
+}
+
+// PermutationCount returns the number of rounds needed by
+// PermutationPlacement to cover every start offset and starting order
+func PermutationCount(config SimulatorConfig) (int, error) {
+	err := config.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	minStart := config.Length + config.Distance
+	if 2*minStart > config.CoreSize {
+		return 0, fmt.Errorf("unable to fit 2 warriors in core")
+	}
+	maxStart := config.CoreSize - minStart
+	return 2 * int(maxStart-minStart+1), nil
+}
+
+// NewSeed returns a seed based on the current time
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// FlagSeed returns the value of the int64 flag name in fs if it was set on
+// the command line, so that any seed including 0 can be chosen, or a seed
+// from NewSeed otherwise
+func FlagSeed(fs *flag.FlagSet, name string) int64 {
+	var seed int64
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			if getter, ok := f.Value.(flag.Getter); ok {
+				seed, set = getter.Get().(int64)
+			}
+		}
+	})
+	if !set {
+		return NewSeed()
+	}
+	return seed
+}
+
+// mixSeed combines seed and n into a new seed using the splitmix64 finalizer
+// so that nearby seeds and rounds give unrelated random sources
+func mixSeed(seed int64, n int64) int64 {
+	z := uint64(seed) + uint64(n+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
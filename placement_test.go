@@ -0,0 +1,162 @@
+package gmars
+
+import (
+	"flag"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceWarriors(t *testing.T) {
+	config := ConfigNOP94
+	rng := rand.New(rand.NewSource(1))
+
+	for n := 1; n <= 6; n++ {
+		for round := 0; round < 100; round++ {
+			offsets, err := PlaceWarriors(config, n, rng)
+			require.NoError(t, err)
+			require.Equal(t, n, len(offsets))
+			require.Equal(t, Address(0), offsets[0])
+
+			for i := 1; i < n; i++ {
+				assert.GreaterOrEqual(t, offsets[i], config.Length+config.Distance)
+				assert.LessOrEqual(t, offsets[i], config.CoreSize-config.Length-config.Distance)
+			}
+			assertSeparated(t, config, offsets)
+		}
+	}
+}
+
+// assertSeparated checks that every pair of offsets is at least
+// Length+Distance apart in both directions around the core
+func assertSeparated(t *testing.T, config SimulatorConfig, offsets []Address) {
+	separation := config.Length + config.Distance
+	for i := range offsets {
+		for j := 0; j < i; j++ {
+			diff := (offsets[i] + config.CoreSize - offsets[j]) % config.CoreSize
+			assert.GreaterOrEqual(t, diff, separation, "%v", offsets)
+			assert.GreaterOrEqual(t, config.CoreSize-diff, separation, "%v", offsets)
+		}
+	}
+}
+
+func TestPlaceWarriorsSmallCore(t *testing.T) {
+	config := NewQuickConfig(NOP94, 200, 200, 2000, 5)
+	rng := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 200; round++ {
+		offsets, err := PlaceWarriors(config, 12, rng)
+		require.NoError(t, err)
+		require.Equal(t, Address(0), offsets[0])
+		assertSeparated(t, config, offsets)
+	}
+}
+
+func TestPlaceWarriorsFull(t *testing.T) {
+	// exactly enough space for 4 warriors at 0, 20, 40, and 60, with 20
+	// addresses from the last warrior back around to the first
+	config := NewQuickConfig(NOP94, 80, 80, 800, 10)
+	rng := rand.New(rand.NewSource(1))
+
+	offsets, err := PlaceWarriors(config, 4, rng)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Address{0, 20, 40, 60}, offsets)
+
+	_, err = PlaceWarriors(config, 5, rng)
+	require.Error(t, err)
+
+	_, err = PlaceWarriors(config, 0, rng)
+	require.Error(t, err)
+}
+
+func TestSeededPlacement(t *testing.T) {
+	config := ConfigNOP94
+
+	placement := SeededPlacement(config, 3, 42)
+	rounds := make([]Placement, 10)
+	for round := range rounds {
+		p, err := placement(round)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(p.Offsets))
+		require.Equal(t, round%3, p.First)
+		rounds[round] = p
+	}
+
+	// rounds only depend on the seed and round number, not call order
+	replay := SeededPlacement(config, 3, 42)
+	for round := len(rounds) - 1; round >= 0; round-- {
+		p, err := replay(round)
+		require.NoError(t, err)
+		assert.Equal(t, rounds[round], p)
+	}
+	assert.NotEqual(t, rounds[0], rounds[1])
+
+	other, err := SeededPlacement(config, 3, 43)(0)
+	require.NoError(t, err)
+	assert.NotEqual(t, rounds[0], other)
+}
+
+func TestPermutationPlacement(t *testing.T) {
+	config := NewQuickConfig(NOP94, 80, 80, 800, 5)
+
+	count, err := PermutationCount(config)
+	require.NoError(t, err)
+	assert.Equal(t, 2*(80+1-2*10), count)
+
+	placement, err := PermutationPlacement(config, 7)
+	require.NoError(t, err)
+
+	type key struct {
+		offset Address
+		first  int
+	}
+	covered := make(map[key]bool)
+	for round := 0; round < count; round++ {
+		p, err := placement(round)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(p.Offsets))
+		assert.Equal(t, Address(0), p.Offsets[0])
+		assert.GreaterOrEqual(t, p.Offsets[1], Address(10))
+		assert.LessOrEqual(t, p.Offsets[1], Address(70))
+		assert.Equal(t, round%2, p.First)
+		covered[key{p.Offsets[1], p.First}] = true
+	}
+	assert.Equal(t, count, len(covered))
+
+	// rounds past the count start over
+	first, err := placement(0)
+	require.NoError(t, err)
+	again, err := placement(count)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	_, err = PermutationPlacement(NewQuickConfig(NOP94, 80, 80, 800, 40), 7)
+	require.Error(t, err)
+}
+
+func TestFixedPlacement(t *testing.T) {
+	placement := FixedPlacement(SeededPlacement(ConfigNOP94, 2, 3), 4000)
+	for round := 0; round < 4; round++ {
+		p, err := placement(round)
+		require.NoError(t, err)
+		assert.Equal(t, []Address{0, 4000}, p.Offsets)
+		assert.Equal(t, round%2, p.First)
+	}
+
+	_, err := FixedPlacement(SeededPlacement(ConfigNOP94, 1, 3), 4000)(0)
+	require.Error(t, err)
+}
+
+func TestFlagSeed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int64("seed", 0, "")
+	require.NoError(t, fs.Parse([]string{"-seed", "0"}))
+	assert.Equal(t, int64(0), FlagSeed(fs, "seed"))
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int64("seed", 0, "")
+	require.NoError(t, fs.Parse([]string{"-seed", "-42"}))
+	assert.Equal(t, int64(-42), FlagSeed(fs, "seed"))
+}
@@ -23,13 +23,16 @@ func (q *processQueue) Len() Address {
 	return q.length
 }
 
-func (q *processQueue) Push(a Address) {
+// Push adds a to the end of the queue and returns false if the queue is
+// full and a was dropped
+func (q *processQueue) Push(a Address) bool {
 	if q.length >= q.size {
-		return
+		return false
 	}
 	q.queue[q.end] = a
 	q.end = (q.end + 1) % q.size
 	q.length++
+	return true
 }
 
 func (q *processQueue) Pop() (Address, error) {
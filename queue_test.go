@@ -15,9 +15,9 @@ func TestQueueEmpty(t *testing.T) {
 func TestQueue(t *testing.T) {
 	pq := newProcessQueue(2)
 
-	pq.Push(1)
-	pq.Push(2)
-	pq.Push(3)
+	require.True(t, pq.Push(1))
+	require.True(t, pq.Push(2))
+	require.False(t, pq.Push(3))
 
 	out, err := pq.Pop()
 	require.NoError(t, err)
@@ -0,0 +1,295 @@
+package gmars
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	recordingMagic   = "GMRC"
+	recordingVersion = 1
+)
+
+// RecordingWriter implements a Reporter which writes a compact binary
+// recording of a battle that can be read back with a RecordingReader.
+//
+// The recording starts with the configuration and warriors of the battle.
+// Each report is written as its type followed by varints of the change in
+// cycle, the warrior index, and the signed distance from the address of the
+// previous report, so most reports take 4 bytes. Report payloads are not
+// recorded.
+//
+// Because each report is encoded relative to the one before it, a
+// RecordingWriter can only record the reports of one Simulator, and it is
+// not safe to share between battles run in parallel with RunBattles. Call
+// Flush after the last round to write out the rest of the recording and
+// check for errors.
+type RecordingWriter struct {
+	w    *bufio.Writer
+	err  error
+	m    Address
+	last Report
+	buf  []byte
+}
+
+// NewRecordingWriter creates a RecordingWriter for a battle between warriors
+// and writes the recording header to w
+func NewRecordingWriter(w io.Writer, config SimulatorConfig, warriors []WarriorData) (*RecordingWriter, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 256)
+	for _, v := range []Address{
+		Address(config.Mode), config.CoreSize, config.Processes, config.Cycles,
+		config.ReadLimit, config.WriteLimit, config.Length, config.Distance, config.PSpaceSize,
+	} {
+		header = binary.AppendUvarint(header, uint64(v))
+	}
+	header = binary.AppendUvarint(header, uint64(len(warriors)))
+	for _, data := range warriors {
+		header = appendString(header, data.Name)
+		header = appendString(header, data.Author)
+		header = appendString(header, data.Strategy)
+		header = binary.AppendUvarint(header, uint64(data.Start))
+		header = binary.AppendUvarint(header, uint64(len(data.Code)))
+		for _, inst := range data.Code {
+			header = appendInstruction(header, inst)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	buf := append([]byte(recordingMagic), recordingVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(header)))
+	_, err = bw.Write(append(buf, header...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecordingWriter{
+		w:   bw,
+		m:   config.CoreSize,
+		buf: make([]byte, 0, 3*binary.MaxVarintLen64+1),
+	}, nil
+}
+
+func (r *RecordingWriter) Report(report Report) {
+	if r.err != nil {
+		return
+	}
+
+	delta := int64(report.Address) - int64(r.last.Address)
+	if delta > int64(r.m/2) {
+		delta -= int64(r.m)
+	} else if delta < -int64(r.m/2) {
+		delta += int64(r.m)
+	}
+
+	buf := append(r.buf[:0], byte(report.Type))
+	buf = binary.AppendVarint(buf, int64(report.Cycle-r.last.Cycle))
+	buf = binary.AppendUvarint(buf, uint64(report.WarriorIndex))
+	buf = binary.AppendVarint(buf, delta)
+	_, r.err = r.w.Write(buf)
+	r.last = report
+}
+
+// Flush writes any buffered output and returns the first error
+// encountered while writing the recording
+func (r *RecordingWriter) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// RecordingReader reads the reports of a recording written by a
+// RecordingWriter
+type RecordingReader struct {
+	Config   SimulatorConfig
+	Warriors []WarriorData
+
+	r    *bufio.Reader
+	last Report
+}
+
+// NewRecordingReader reads the header of a recording from r
+func NewRecordingReader(r io.Reader) (*RecordingReader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(recordingMagic)+1)
+	_, err := io.ReadFull(br, magic)
+	if err != nil || string(magic[:len(recordingMagic)]) != recordingMagic {
+		return nil, fmt.Errorf("invalid recording header")
+	}
+	if magic[len(recordingMagic)] != recordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", magic[len(recordingMagic)])
+	}
+
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %s", err)
+	}
+	if size > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid header size %d", size)
+	}
+	header := make([]byte, size)
+	_, err = io.ReadFull(br, header)
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %s", err)
+	}
+
+	config, warriors, err := readRecordingHeader(bytes.NewReader(header))
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %s", err)
+	}
+
+	return &RecordingReader{
+		Config:   config,
+		Warriors: warriors,
+		r:        br,
+	}, nil
+}
+
+func readRecordingHeader(r *bytes.Reader) (SimulatorConfig, []WarriorData, error) {
+	var values [9]Address
+	for i := range values {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return SimulatorConfig{}, nil, err
+		}
+		if v > math.MaxInt32 {
+			return SimulatorConfig{}, nil, fmt.Errorf("invalid config value %d", v)
+		}
+		values[i] = Address(v)
+	}
+	config := SimulatorConfig{
+		Mode:       SimulatorMode(values[0]),
+		CoreSize:   values[1],
+		Processes:  values[2],
+		Cycles:     values[3],
+		ReadLimit:  values[4],
+		WriteLimit: values[5],
+		Length:     values[6],
+		Distance:   values[7],
+		PSpaceSize: values[8],
+	}
+	err := config.Validate()
+	if err != nil {
+		return SimulatorConfig{}, nil, err
+	}
+
+	count, err := readCount(r)
+	if err != nil {
+		return SimulatorConfig{}, nil, err
+	}
+	warriors := make([]WarriorData, count)
+	for i := range warriors {
+		data := &warriors[i]
+		for _, field := range []*string{&data.Name, &data.Author, &data.Strategy} {
+			*field, err = readString(r)
+			if err != nil {
+				return SimulatorConfig{}, nil, fmt.Errorf("warrior %d: %s", i, err)
+			}
+		}
+		start, err := binary.ReadUvarint(r)
+		if err != nil {
+			return SimulatorConfig{}, nil, fmt.Errorf("warrior %d: %s", i, err)
+		}
+		length, err := readCount(r)
+		if err != nil {
+			return SimulatorConfig{}, nil, fmt.Errorf("warrior %d: %s", i, err)
+		}
+		if start > uint64(length) {
+			return SimulatorConfig{}, nil, fmt.Errorf("warrior %d: invalid start %d", i, start)
+		}
+		data.Start = int(start)
+		data.Code = make([]Instruction, length)
+		for j := range data.Code {
+			data.Code[j], err = readInstruction(r)
+			if err != nil {
+				return SimulatorConfig{}, nil, fmt.Errorf("warrior %d: %s", i, err)
+			}
+		}
+	}
+
+	if r.Len() != 0 {
+		return SimulatorConfig{}, nil, fmt.Errorf("unexpected data after header")
+	}
+	return config, warriors, nil
+}
+
+// Next returns the next report in the recording, or io.EOF at the end of
+// the recording
+func (r *RecordingReader) Next() (Report, error) {
+	t, err := r.r.ReadByte()
+	if err != nil {
+		return Report{}, err
+	}
+	if int(t) >= len(reportTypeNames) {
+		return Report{}, fmt.Errorf("invalid report type %d", t)
+	}
+
+	cycleDelta, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return Report{}, unexpectedEOF(err)
+	}
+	warrior, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return Report{}, unexpectedEOF(err)
+	}
+	addressDelta, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return Report{}, unexpectedEOF(err)
+	}
+
+	cycle := int64(r.last.Cycle) + cycleDelta
+	if cycle < 0 || cycle > math.MaxInt32 {
+		return Report{}, fmt.Errorf("invalid cycle %d", cycle)
+	}
+	if warrior > 0 && warrior >= uint64(len(r.Warriors)) {
+		return Report{}, fmt.Errorf("invalid warrior index %d", warrior)
+	}
+	m := int64(r.Config.CoreSize)
+	address := ((int64(r.last.Address)+addressDelta)%m + m) % m
+
+	r.last = Report{
+		Type:         ReportType(t),
+		Cycle:        int(cycle),
+		WarriorIndex: int(warrior),
+		Address:      Address(address),
+	}
+	return r.last, nil
+}
+
+// unexpectedEOF converts io.EOF in the middle of a report to
+// io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,108 @@
+package gmars
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestWarriors(t *testing.T, config SimulatorConfig, names ...string) []WarriorData {
+	warriors := make([]WarriorData, 0)
+	for _, name := range names {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, config)
+		require.NoError(t, err)
+		warriors = append(warriors, data)
+	}
+	return warriors
+}
+
+// recordBattle runs a battle and returns its recording and every report
+// with the payloads removed
+func recordBattle(t *testing.T, config SimulatorConfig, warriors []WarriorData, rounds int) ([]byte, []Report) {
+	buf := &bytes.Buffer{}
+	writer, err := NewRecordingWriter(buf, config, warriors)
+	require.NoError(t, err)
+	list := &listReporter{}
+
+	runner, err := NewBattleRunner(config, warriors)
+	require.NoError(t, err)
+	runner.SetSeed(7)
+	runner.SetReporterFunc(func(sim ReportingSimulator) {
+		sim.AddReporter(writer)
+		sim.AddReporter(list)
+	})
+	_, err = runner.Run(context.Background(), rounds)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+
+	for i, report := range list.reports {
+		list.reports[i] = Report{
+			Type:         report.Type,
+			Cycle:        report.Cycle,
+			WarriorIndex: report.WarriorIndex,
+			Address:      report.Address,
+		}
+	}
+	return buf.Bytes(), list.reports
+}
+
+func TestRecording(t *testing.T) {
+	config := ConfigNOP94
+	config.Cycles = 2000
+	warriors := loadTestWarriors(t, config, "warriors/94/simpleshot.red", "warriors/94/paperhaze.red")
+	data, reports := recordBattle(t, config, warriors, 2)
+
+	reader, err := NewRecordingReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, config, reader.Config)
+	assert.Equal(t, warriors, reader.Warriors)
+
+	read := make([]Report, 0, len(reports))
+	for {
+		report, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		read = append(read, report)
+	}
+	assert.Equal(t, reports, read)
+
+	// most reports are within the same cycle and close to the previous
+	// address
+	assert.Less(t, len(data), len(reports)*5)
+}
+
+func TestRecordingInvalid(t *testing.T) {
+	config := ConfigNOP94
+	config.Cycles = 100
+	warriors := loadTestWarriors(t, config, "warriors/94/imp.red")
+	data, _ := recordBattle(t, config, warriors, 1)
+
+	_, err := NewRecordingReader(bytes.NewReader([]byte("GMSS\x01")))
+	require.Error(t, err)
+
+	badVersion := append([]byte{}, data...)
+	badVersion[4] = 99
+	_, err = NewRecordingReader(bytes.NewReader(badVersion))
+	require.Error(t, err)
+
+	_, err = NewRecordingReader(bytes.NewReader(data[:20]))
+	require.Error(t, err)
+
+	// cut off in the middle of the last report
+	reader, err := NewRecordingReader(bytes.NewReader(data[:len(data)-1]))
+	require.NoError(t, err)
+	for err == nil {
+		_, err = reader.Next()
+	}
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,198 @@
+package gmars
+
+import (
+	"fmt"
+	"io"
+)
+
+// Replay plays back a recording read by a RecordingReader, sending each
+// report to its reporters without simulating the battle.
+//
+// The state of the warriors is rebuilt from the spawn, process queue and
+// termination reports, so a Replay can be used in place of a Simulator to
+// display a battle with a StateRecorder. Core contents are not recorded, so
+// only the code loaded when the warriors were spawned is known.
+type Replay struct {
+	reader    *RecordingReader
+	sim       *reportSim
+	reporters []Reporter
+	masks     []ReportMask
+
+	next    *Report
+	started bool
+	err     error
+}
+
+// NewReplay creates a Replay of the recording read by r
+func NewReplay(r *RecordingReader) (*Replay, error) {
+	if len(r.Warriors) == 0 {
+		return nil, fmt.Errorf("no warriors in recording")
+	}
+	sim, err := newReportSim(r.Config)
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.Warriors {
+		_, err := sim.addWarrior(&r.Warriors[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Replay{reader: r, sim: sim}, nil
+}
+
+// AddReporter adds a reporter to receive the reports played back
+func (p *Replay) AddReporter(r Reporter) {
+	p.reporters = append(p.reporters, r)
+	p.masks = append(p.masks, reporterMask(r))
+}
+
+func (p *Replay) CoreSize() Address {
+	return p.sim.CoreSize()
+}
+
+func (p *Replay) CycleCount() int {
+	return p.sim.CycleCount()
+}
+
+func (p *Replay) MaxCycles() int {
+	return p.sim.MaxCycles()
+}
+
+func (p *Replay) GetWarrior(wi int) Warrior {
+	return p.sim.GetWarrior(wi)
+}
+
+func (p *Replay) WarriorCount() int {
+	return p.sim.WarriorCount()
+}
+
+func (p *Replay) WarriorLivingCount() int {
+	return p.sim.WarriorLivingCount()
+}
+
+// Err returns the error that stopped the replay, if any. Reaching the end
+// of the recording is not an error.
+func (p *Replay) Err() error {
+	return p.err
+}
+
+// NextRound plays the rest of the current round and the start of the next
+// one, up to its first cycle. It returns false if there are no more rounds
+// in the recording.
+func (p *Replay) NextRound() bool {
+	if p.started {
+		for {
+			report, ok := p.peek()
+			if !ok {
+				return false
+			}
+			if report.Type == SimReset {
+				break
+			}
+			p.play()
+		}
+	}
+	p.started = true
+
+	spawned := false
+	for {
+		report, ok := p.peek()
+		if !ok || report.Type == CycleStart || (report.Type == SimReset && spawned) {
+			return spawned
+		}
+		p.play()
+		spawned = spawned || report.Type == WarriorSpawn
+	}
+}
+
+// RunCycle plays the reports of the next cycle and returns false if the
+// round is over
+func (p *Replay) RunCycle() bool {
+	started := false
+	for {
+		report, ok := p.peek()
+		if !ok || report.Type == SimReset || (report.Type == CycleStart && started) {
+			return started
+		}
+		started = started || report.Type == CycleStart
+		p.play()
+		if report.Type == CycleEnd {
+			return true
+		}
+	}
+}
+
+// peek returns the next report without playing it
+func (p *Replay) peek() (Report, bool) {
+	if p.next != nil {
+		return *p.next, true
+	}
+	if p.err != nil {
+		return Report{}, false
+	}
+	report, err := p.reader.Next()
+	if err != nil {
+		if err != io.EOF {
+			p.err = err
+		}
+		return Report{}, false
+	}
+	p.next = &report
+	return report, true
+}
+
+// play applies the next report to the warriors and sends it to the
+// reporters
+func (p *Replay) play() {
+	report := *p.next
+	p.next = nil
+
+	err := p.apply(report)
+	if err != nil {
+		p.err = fmt.Errorf("cycle %d: %s", report.Cycle, err)
+		return
+	}
+
+	for i, r := range p.reporters {
+		if p.masks[i].Has(report.Type) {
+			r.Report(report)
+		}
+	}
+}
+
+func (p *Replay) apply(report Report) error {
+	s := p.sim
+	if report.WarriorIndex >= s.warriorCount {
+		return fmt.Errorf("warrior index out of bounds")
+	}
+	w := s.warriors[report.WarriorIndex]
+
+	switch report.Type {
+	case SimReset:
+		s.Reset()
+	case CycleStart:
+		s.cycleCount = Address(report.Cycle)
+	case CycleEnd:
+		s.cycleCount = Address(report.Cycle + 1)
+	case WarriorSpawn:
+		return s.spawnWarrior(report.WarriorIndex, report.Address)
+	case WarriorTaskPop:
+		if w.state != WarriorAlive {
+			return fmt.Errorf("warrior %d is not alive", w.index)
+		}
+		_, err := w.pq.Pop()
+		return err
+	case WarriorTaskPush:
+		if w.state != WarriorAlive {
+			return fmt.Errorf("warrior %d is not alive", w.index)
+		}
+		w.pq.Push(report.Address)
+	case WarriorTerminate:
+		if w.state == WarriorAlive {
+			w.state = WarriorDead
+			s.warriorLivingCount--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gmars
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	config := ConfigNOP94
+	config.Cycles = 3000
+	warriors := loadTestWarriors(t, config, "warriors/94/bombspiral.red", "warriors/94/paperhaze.red")
+	placement := SeededPlacement(config, len(warriors), 7)
+
+	// record two rounds, keeping the simulator so the first round can be
+	// compared cycle by cycle
+	sim, err := NewReportingSimulator(config)
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	writer, err := NewRecordingWriter(buf, config, warriors)
+	require.NoError(t, err)
+	sim.AddReporter(writer)
+	for i := range warriors {
+		_, err := sim.AddWarrior(&warriors[i])
+		require.NoError(t, err)
+	}
+
+	states := make([]*Snapshot, 0)
+	for round := 0; round < 2; round++ {
+		if round > 0 {
+			sim.Reset()
+		}
+		p, err := placement(round)
+		require.NoError(t, err)
+		for wi, offset := range p.Offsets {
+			require.NoError(t, sim.SpawnWarrior(wi, offset))
+		}
+		require.NoError(t, sim.SetFirstWarrior(p.First))
+		states = append(states, sim.Snapshot())
+		for sim.WarriorLivingCount() > 1 && sim.CycleCount() < sim.MaxCycles() {
+			sim.RunCycle()
+			if round == 0 {
+				states = append(states, sim.Snapshot())
+			}
+		}
+	}
+	require.NoError(t, writer.Flush())
+
+	reader, err := NewRecordingReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	replay, err := NewReplay(reader)
+	require.NoError(t, err)
+	rec := NewStateRecorder(replay)
+	replay.AddReporter(rec)
+
+	checkState := func(snapshot *Snapshot) {
+		require.Equal(t, snapshot.CycleCount, replay.CycleCount())
+		living := 0
+		for wi, ws := range snapshot.Warriors {
+			w := replay.GetWarrior(wi)
+			require.Equal(t, ws.State == WarriorAlive, w.Alive())
+			if w.Alive() {
+				living++
+				require.Equal(t, ws.Queue, w.Queue())
+			}
+		}
+		require.Equal(t, living, replay.WarriorLivingCount())
+	}
+
+	require.True(t, replay.NextRound())
+	checkState(states[0])
+	cycles := 0
+	for replay.RunCycle() {
+		cycles++
+		checkState(states[cycles])
+	}
+	assert.Equal(t, len(states)-2, cycles)
+
+	require.True(t, replay.NextRound())
+	checkState(states[len(states)-1])
+	state, color := rec.GetMemState(states[len(states)-1].Warriors[0].Queue[0])
+	assert.Equal(t, CoreWritten, state)
+	assert.Equal(t, 0, color)
+
+	assert.False(t, replay.NextRound())
+	require.NoError(t, replay.Err())
+}
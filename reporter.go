@@ -19,19 +19,121 @@ const (
 	WarriorWrite
 	WarriorDecrement
 	WarriorIncrement
+	WarriorProcessLimit
+	WarriorPSpaceWrite
 )
 
+var reportTypeNames = []string{
+	SimReset:             "SimReset",
+	CycleStart:           "CycleStart",
+	CycleEnd:             "CycleEnd",
+	WarriorSpawn:         "WarriorSpawn",
+	WarriorTaskPop:       "WarriorTaskPop",
+	WarriorTaskPush:      "WarriorTaskPush",
+	WarriorTaskTerminate: "WarriorTaskTerminate",
+	WarriorTerminate:     "WarriorTerminate",
+	WarriorRead:          "WarriorRead",
+	WarriorWrite:         "WarriorWrite",
+	WarriorDecrement:     "WarriorDecrement",
+	WarriorIncrement:     "WarriorIncrement",
+	WarriorProcessLimit:  "WarriorProcessLimit",
+	WarriorPSpaceWrite:   "WarriorPSpaceWrite",
+}
+
+func (t ReportType) String() string {
+	if int(t) < len(reportTypeNames) {
+		return reportTypeNames[t]
+	}
+	return fmt.Sprintf("ReportType(%d)", t)
+}
+
+// MarshalText encodes the report type as its name
+func (t ReportType) MarshalText() ([]byte, error) {
+	if int(t) >= len(reportTypeNames) {
+		return nil, fmt.Errorf("invalid report type: %d", t)
+	}
+	return []byte(reportTypeNames[t]), nil
+}
+
+// UnmarshalText decodes a report type from its name
+func (t *ReportType) UnmarshalText(text []byte) error {
+	for i, name := range reportTypeNames {
+		if name == string(text) {
+			*t = ReportType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid report type: '%s'", text)
+}
+
+// Report describes an event in the simulator. Cycle, WarriorIndex and
+// Address are set for every warrior event, and the payload fields are only
+// set for the report types documented below. For WarriorPSpaceWrite, Address
+// is the P-space index written by STP.
 type Report struct {
 	Type         ReportType
 	Cycle        int
 	WarriorIndex int
 	Address      Address
+
+	// Instruction is the instruction executed for WarriorTaskPop, the
+	// instruction read for WarriorRead, and the instruction at Address after
+	// the change for WarriorWrite, WarriorIncrement and WarriorDecrement. For
+	// WarriorPSpaceWrite, Instruction.B is the value stored.
+	Instruction Instruction
+
+	// Before is the instruction at Address before the change for
+	// WarriorWrite, WarriorIncrement and WarriorDecrement. For
+	// WarriorPSpaceWrite, Before.B is the value replaced.
+	Before Instruction
+
+	// QueueLen is the length of the process queue after the pop for
+	// WarriorTaskPop, after the push for WarriorTaskPush, and the process
+	// limit for WarriorProcessLimit
+	QueueLen int
 }
 
 type Reporter interface {
 	Report(r Report)
 }
 
+// ReportMask is a set of ReportTypes
+type ReportMask uint32
+
+// ReportAll is a ReportMask containing every ReportType
+const ReportAll ReportMask = ^ReportMask(0)
+
+// NewReportMask returns a ReportMask containing types
+func NewReportMask(types ...ReportType) ReportMask {
+	var mask ReportMask
+	for _, t := range types {
+		mask |= 1 << t
+	}
+	return mask
+}
+
+// Has returns true if t is in the mask
+func (m ReportMask) Has(t ReportType) bool {
+	return m&(1<<t) != 0
+}
+
+// FilteredReporter is a Reporter that only receives the report types in its
+// ReportMask. The mask is read once when the reporter is added to a
+// simulator, and reports of other types are not built or sent.
+type FilteredReporter interface {
+	Reporter
+	ReportMask() ReportMask
+}
+
+// reporterMask returns the ReportMask of r, or ReportAll if r is not a
+// FilteredReporter
+func reporterMask(r Reporter) ReportMask {
+	if fr, ok := r.(FilteredReporter); ok {
+		return fr.ReportMask()
+	}
+	return ReportAll
+}
+
 type debugReporter struct {
 	s Simulator
 }
@@ -64,5 +166,9 @@ func (r *debugReporter) Report(report Report) {
 		fmt.Printf("W%02d %04d: Increment\n", report.WarriorIndex, report.Address)
 	case WarriorDecrement:
 		fmt.Printf("W%02d %04d: Decrement\n", report.WarriorIndex, report.Address)
+	case WarriorProcessLimit:
+		fmt.Printf("W%02d: Process Limit %04d\n", report.WarriorIndex, report.Address)
+	case WarriorPSpaceWrite:
+		fmt.Printf("W%02d: P-Space Write %d = %d\n", report.WarriorIndex, report.Address, report.Instruction.B)
 	}
 }
@@ -0,0 +1,290 @@
+package gmars
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countReporter counts the reports received of each type
+type countReporter struct {
+	mask   ReportMask
+	counts map[ReportType]int
+}
+
+func (r *countReporter) Report(report Report) {
+	r.counts[report.Type]++
+}
+
+// filteredCountReporter is a countReporter that only accepts r.mask
+type filteredCountReporter struct {
+	countReporter
+}
+
+func (r *filteredCountReporter) ReportMask() ReportMask {
+	return r.mask
+}
+
+func TestReportMask(t *testing.T) {
+	mask := NewReportMask(WarriorWrite, WarriorTerminate)
+	assert.True(t, mask.Has(WarriorWrite))
+	assert.True(t, mask.Has(WarriorTerminate))
+	assert.False(t, mask.Has(WarriorRead))
+	assert.False(t, mask.Has(SimReset))
+	assert.True(t, ReportAll.Has(SimReset))
+	assert.True(t, ReportAll.Has(WarriorIncrement))
+	assert.Equal(t, ReportMask(0), NewReportMask())
+}
+
+func TestFilteredReporter(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+
+	all := &countReporter{counts: make(map[ReportType]int)}
+	filtered := &filteredCountReporter{countReporter{
+		mask:   NewReportMask(WarriorWrite, WarriorTerminate),
+		counts: make(map[ReportType]int),
+	}}
+	sim.AddReporter(all)
+	sim.AddReporter(filtered)
+
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	dat := WarriorData{Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+	_, err = sim.AddWarrior(&imp)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&dat)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	require.NoError(t, sim.SpawnWarrior(1, 4000))
+	sim.Run()
+
+	assert.Equal(t, map[ReportType]int{WarriorWrite: 1, WarriorTerminate: 1}, filtered.counts)
+	assert.Equal(t, 1, all.counts[WarriorWrite])
+	assert.Equal(t, 1, all.counts[WarriorTerminate])
+	assert.Equal(t, 2, all.counts[WarriorTaskPop])
+	assert.Equal(t, 1, all.counts[CycleStart])
+	assert.Equal(t, 2, all.counts[WarriorSpawn])
+}
+
+func TestStateRecorderReportMask(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	rec := NewStateRecorder(sim)
+
+	assert.False(t, rec.ReportMask().Has(WarriorRead))
+	assert.False(t, rec.ReportMask().Has(CycleStart))
+	assert.True(t, rec.ReportMask().Has(WarriorWrite))
+
+	rec.SetRecordRead(true)
+	assert.True(t, rec.ReportMask().Has(WarriorRead))
+}
+
+// listReporter keeps every report received
+type listReporter struct {
+	reports []Report
+}
+
+func (r *listReporter) Report(report Report) {
+	r.reports = append(r.reports, report)
+}
+
+func (r *listReporter) ofType(t ReportType) []Report {
+	out := make([]Report, 0)
+	for _, report := range r.reports {
+		if report.Type == t {
+			out = append(out, report)
+		}
+	}
+	return out
+}
+
+// runReporterTest creates a simulator, calls attach to add the reporters
+// under test, and adds warriors compiled from code. It then runs a round for
+// each entry of cycles, with the warriors spawned at offsets, for that many
+// cycles.
+func runReporterTest(t *testing.T, code []string, offsets []Address, cycles []int, attach func(sim ReportingSimulator)) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	attach(sim)
+
+	for _, c := range code {
+		data, err := CompileWarrior(strings.NewReader(c), ConfigNOP94)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&data)
+		require.NoError(t, err)
+	}
+
+	for round, n := range cycles {
+		if round > 0 {
+			sim.Reset()
+		}
+		for wi, offset := range offsets {
+			require.NoError(t, sim.SpawnWarrior(wi, offset))
+		}
+		for i := 0; i < n; i++ {
+			sim.RunCycle()
+		}
+	}
+}
+
+func runPayloadTest(t *testing.T, config SimulatorConfig, code string, cycles int) *listReporter {
+	data, err := CompileWarrior(strings.NewReader(code), config)
+	require.NoError(t, err)
+
+	sim, err := NewReportingSimulator(config)
+	require.NoError(t, err)
+	rec := &listReporter{}
+	sim.AddReporter(rec)
+	_, err = sim.AddWarrior(&data)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	for i := 0; i < cycles; i++ {
+		sim.RunCycle()
+	}
+	return rec
+}
+
+func TestReportPayloadWrite(t *testing.T) {
+	rec := runPayloadTest(t, ConfigNOP94, "add.ab #4, $3\nmov.i $2, @2\njmp $-2\ndat #0, #0\n", 2)
+
+	pops := rec.ofType(WarriorTaskPop)
+	require.Len(t, pops, 2)
+	assert.Equal(t, Instruction{Op: ADD, OpMode: AB, AMode: IMMEDIATE, A: 4, BMode: DIRECT, B: 3}, pops[0].Instruction)
+	assert.Equal(t, 0, pops[0].QueueLen)
+	assert.Equal(t, 1, pops[1].Cycle)
+	assert.Equal(t, MOV, pops[1].Instruction.Op)
+
+	bomb := Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 4}
+	writes := rec.ofType(WarriorWrite)
+	require.Len(t, writes, 2)
+	assert.Equal(t, Address(3), writes[0].Address)
+	assert.Equal(t, 0, writes[0].Cycle)
+	assert.Equal(t, Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE}, writes[0].Before)
+	assert.Equal(t, bomb, writes[0].Instruction)
+	assert.Equal(t, Address(7), writes[1].Address)
+	assert.Equal(t, 1, writes[1].Cycle)
+	assert.Equal(t, Instruction{}, writes[1].Before)
+	assert.Equal(t, bomb, writes[1].Instruction)
+}
+
+func TestReportPayloadIncrement(t *testing.T) {
+	rec := runPayloadTest(t, ConfigNOP94, "mov.i }1, $1\n", 1)
+
+	incs := rec.ofType(WarriorIncrement)
+	require.Len(t, incs, 1)
+	assert.Equal(t, Address(1), incs[0].Address)
+	assert.Equal(t, Instruction{}, incs[0].Before)
+	assert.Equal(t, Instruction{A: 1}, incs[0].Instruction)
+
+	// the write happens after the increment and copies the value read
+	// before it
+	writes := rec.ofType(WarriorWrite)
+	require.Len(t, writes, 1)
+	assert.Equal(t, Instruction{A: 1}, writes[0].Before)
+	assert.Equal(t, Instruction{}, writes[0].Instruction)
+}
+
+func TestReportPayloadDecrement(t *testing.T) {
+	rec := runPayloadTest(t, ConfigNOP94, "djn.b $0, #2\n", 1)
+
+	decs := rec.ofType(WarriorDecrement)
+	require.Len(t, decs, 1)
+	assert.Equal(t, Address(0), decs[0].Address)
+	assert.Equal(t, Address(2), decs[0].Before.B)
+	assert.Equal(t, Address(1), decs[0].Instruction.B)
+	assert.Equal(t, DJN, decs[0].Instruction.Op)
+}
+
+func TestReportReads(t *testing.T) {
+	rec := runPayloadTest(t, ConfigNOP94, "mov.i @2, $3\njmz.b $-1, $2\ndat #0, #4\n", 2)
+
+	type read struct {
+		Address Address
+		Op      OpCode
+	}
+	reads := make([]read, 0)
+	for _, report := range rec.ofType(WarriorRead) {
+		reads = append(reads, read{report.Address, report.Instruction.Op})
+	}
+
+	// mov reads the pointer at 2 and the source at 6, and jmz only reads
+	// the B operand it tests
+	assert.Equal(t, []read{{2, DAT}, {6, DAT}, {3, DAT}}, reads)
+}
+
+func TestReportPayloadPSpaceWrite(t *testing.T) {
+	rec := runPayloadTest(t, ConfigNOP94, "stp.ab #5, #3\nstp.ab #6, #3\n", 2)
+
+	writes := rec.ofType(WarriorPSpaceWrite)
+	require.Len(t, writes, 2)
+	assert.Equal(t, Address(3), writes[0].Address)
+	assert.Equal(t, Address(0), writes[0].Before.B)
+	assert.Equal(t, Address(5), writes[0].Instruction.B)
+	assert.Equal(t, 1, writes[1].Cycle)
+	assert.Equal(t, Address(5), writes[1].Before.B)
+	assert.Equal(t, Address(6), writes[1].Instruction.B)
+}
+
+func TestReportPushes(t *testing.T) {
+	config := ConfigNOP94
+	config.Processes = 3
+	rec := runPayloadTest(t, config, "spl $0\njmp $-1\n", 4)
+
+	pushes := rec.ofType(WarriorTaskPush)
+	require.Len(t, pushes, 6)
+	addresses := make([]Address, len(pushes))
+	for i, push := range pushes {
+		addresses[i] = push.Address
+	}
+	assert.Equal(t, []Address{1, 0, 0, 1, 0, 1}, addresses)
+	assert.Equal(t, 2, pushes[1].QueueLen)
+	assert.Equal(t, 3, pushes[5].QueueLen)
+
+	limits := rec.ofType(WarriorProcessLimit)
+	require.Len(t, limits, 1)
+	assert.Equal(t, 3, limits[0].Cycle)
+	assert.Equal(t, Address(0), limits[0].Address)
+	assert.Equal(t, 3, limits[0].QueueLen)
+}
+
+func TestReportJumpPushes(t *testing.T) {
+	tests := []struct {
+		code string
+		next Address
+	}{
+		{"jmz.b $2, #1\n", 1},
+		{"jmz.b $2, #0\n", 2},
+		{"jmn.b $2, #1\n", 2},
+		{"jmn.b $2, #0\n", 1},
+		{"djn.b $2, #2\n", 2},
+		{"djn.b $2, #1\n", 1},
+		{"nop $0\n", 1},
+	}
+
+	for _, test := range tests {
+		rec := runPayloadTest(t, ConfigNOP94, test.code, 1)
+		pushes := rec.ofType(WarriorTaskPush)
+		require.Len(t, pushes, 1, test.code)
+		assert.Equal(t, test.next, pushes[0].Address, test.code)
+	}
+}
+
+func TestReportTypeString(t *testing.T) {
+	assert.Equal(t, "SimReset", SimReset.String())
+	assert.Equal(t, "WarriorProcessLimit", WarriorProcessLimit.String())
+	assert.Equal(t, "ReportType(200)", ReportType(200).String())
+
+	for _, rt := range []ReportType{SimReset, WarriorTaskPop, WarriorIncrement, WarriorProcessLimit} {
+		text, err := rt.MarshalText()
+		require.NoError(t, err)
+		var decoded ReportType
+		require.NoError(t, decoded.UnmarshalText(text))
+		assert.Equal(t, rt, decoded)
+	}
+
+	_, err := ReportType(200).MarshalText()
+	require.Error(t, err)
+}
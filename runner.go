@@ -0,0 +1,290 @@
+package gmars
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BattleResult holds the totals of each warrior over every round of a
+// battle. Wins are rounds where the warrior was the only survivor, and ties
+// are rounds where it survived with other warriors.
+type BattleResult struct {
+	Rounds int
+	Wins   []int
+	Losses []int
+	Ties   []int
+	Scores []int
+}
+
+// BattleRunner runs the rounds of a battle on a pool of workers, each with
+// its own Simulator.
+//
+// The rounds are split into contiguous blocks, one for each worker, and each
+// block is run in order on the worker's Simulator. Warriors using LDP or STP
+// keep their P-space between rounds as in pMARS, so their battles are always
+// run in order on a single Simulator. Battles can also be run in parallel
+// with RunBattles.
+type BattleRunner struct {
+	config       SimulatorConfig
+	warriors     []WarriorData
+	workers      int
+	seed         int64
+	placement    PlacementFunc
+	reporterFunc func(sim ReportingSimulator)
+}
+
+// NewBattleRunner creates a BattleRunner for warriors using one worker.
+// Warriors are placed with SeededPlacement using a seed from NewSeed.
+func NewBattleRunner(config SimulatorConfig, warriors []WarriorData) (*BattleRunner, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if len(warriors) == 0 {
+		return nil, fmt.Errorf("no warriors specified")
+	}
+
+	runner := &BattleRunner{
+		config:   config,
+		warriors: warriors,
+		workers:  1,
+	}
+	runner.SetSeed(NewSeed())
+	return runner, nil
+}
+
+// SetWorkers sets the number of workers running rounds in parallel, or one
+// for each CPU if workers is less than 1. It has no effect if any warrior
+// uses P-space.
+func (r *BattleRunner) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	r.workers = workers
+}
+
+// SetSeed places warriors with SeededPlacement using seed
+func (r *BattleRunner) SetSeed(seed int64) {
+	r.seed = seed
+	r.placement = SeededPlacement(r.config, len(r.warriors), seed)
+}
+
+// Seed returns the seed of the runner's placements. It is not meaningful
+// after SetPlacement has been called.
+func (r *BattleRunner) Seed() int64 {
+	return r.seed
+}
+
+// SetPlacement sets the function used to place warriors each round. It is
+// called once for each round, in order, before any rounds are run.
+func (r *BattleRunner) SetPlacement(placement PlacementFunc) {
+	r.placement = placement
+}
+
+// SetReporterFunc sets a function called with the Simulator of each worker
+// before any rounds are run, which can be used to attach reporters. It is
+// called for the workers in order, from a single goroutine, and each worker
+// runs the rounds following those of the previous worker, so reporters
+// collected in the order they are created hold the rounds in order.
+func (r *BattleRunner) SetReporterFunc(f func(sim ReportingSimulator)) {
+	r.reporterFunc = f
+}
+
+// Run runs the battle for the given number of rounds. If ctx is cancelled,
+// the workers stop after their current round and ctx.Err() is returned.
+func (r *BattleRunner) Run(ctx context.Context, rounds int) (BattleResult, error) {
+	if rounds < 1 {
+		return BattleResult{}, fmt.Errorf("invalid round count")
+	}
+
+	placements := make([]Placement, rounds)
+	for round := range placements {
+		placement, err := r.placement(round)
+		if err != nil {
+			return BattleResult{}, err
+		}
+		if len(placement.Offsets) != len(r.warriors) {
+			return BattleResult{}, fmt.Errorf("round %d: expected %d offsets, got %d", round, len(r.warriors), len(placement.Offsets))
+		}
+		if placement.First < 0 || placement.First >= len(r.warriors) {
+			return BattleResult{}, fmt.Errorf("round %d: invalid first warrior %d", round, placement.First)
+		}
+		placements[round] = placement
+	}
+
+	workers := r.workers
+	if workers > rounds {
+		workers = rounds
+	}
+	if usesPSpace(r.warriors) {
+		workers = 1
+	}
+
+	sims := make([]ReportingSimulator, workers)
+	for worker := range sims {
+		sim, err := r.newSimulator()
+		if err != nil {
+			return BattleResult{}, err
+		}
+		sims[worker] = sim
+	}
+
+	outcomes := make([][]bool, rounds)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for worker, sim := range sims {
+		wg.Add(1)
+		go func(worker int, sim ReportingSimulator) {
+			defer wg.Done()
+			start := worker * rounds / workers
+			end := (worker + 1) * rounds / workers
+			errs[worker] = runRounds(ctx, sim, placements[start:end], outcomes[start:end])
+		}(worker, sim)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return BattleResult{}, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return BattleResult{}, err
+		}
+	}
+
+	n := len(r.warriors)
+	result := BattleResult{
+		Rounds: rounds,
+		Wins:   make([]int, n),
+		Losses: make([]int, n),
+		Ties:   make([]int, n),
+		Scores: make([]int, n),
+	}
+	for _, alive := range outcomes {
+		result.addRound(alive)
+	}
+	return result, nil
+}
+
+// newSimulator creates a Simulator for a worker with the reporters from the
+// reporter function and the warriors of the battle
+func (r *BattleRunner) newSimulator() (ReportingSimulator, error) {
+	sim, err := NewReportingSimulator(r.config)
+	if err != nil {
+		return nil, err
+	}
+	if r.reporterFunc != nil {
+		r.reporterFunc(sim)
+	}
+	for i := range r.warriors {
+		_, err := sim.AddWarrior(&r.warriors[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sim, nil
+}
+
+// runRounds runs a round for each placement in order on sim and stores the
+// surviving warriors of each round in outcomes
+func runRounds(ctx context.Context, sim ReportingSimulator, placements []Placement, outcomes [][]bool) error {
+	for round, placement := range placements {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if round > 0 {
+			sim.Reset()
+		}
+		for wi, offset := range placement.Offsets {
+			err := sim.SpawnWarrior(wi, offset)
+			if err != nil {
+				return err
+			}
+		}
+		err := sim.SetFirstWarrior(placement.First)
+		if err != nil {
+			return err
+		}
+		outcomes[round], _ = sim.Run()
+	}
+	return nil
+}
+
+// usesPSpace returns true if any of warriors uses LDP or STP
+func usesPSpace(warriors []WarriorData) bool {
+	for _, warrior := range warriors {
+		for _, inst := range warrior.Code {
+			if inst.Op == LDP || inst.Op == STP {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RunBattles runs each battle for the given number of rounds using workers
+// goroutines, or one for each CPU if workers is less than 1. Each battle is
+// run by a single worker, so the results do not depend on the number of
+// workers. The results are returned in the order of runners.
+func RunBattles(ctx context.Context, runners []*BattleRunner, rounds, workers int) ([]BattleResult, error) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(runners) {
+		workers = len(runners)
+	}
+
+	results := make([]BattleResult, len(runners))
+	errs := make([]error, len(runners))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = runners[i].Run(ctx, rounds)
+			}
+		}()
+	}
+	for i := range runners {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (r *BattleResult) addRound(alive []bool) {
+	survivors := 0
+	for _, a := range alive {
+		if a {
+			survivors++
+		}
+	}
+
+	for i, a := range alive {
+		if !a {
+			r.Losses[i]++
+		} else if survivors == 1 {
+			r.Wins[i]++
+		} else {
+			r.Ties[i]++
+		}
+	}
+
+	for i, score := range RoundScores(alive) {
+		r.Scores[i] += score
+	}
+}
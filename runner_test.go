@@ -0,0 +1,230 @@
+package gmars
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBattleRunner(t *testing.T) {
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	dat := WarriorData{Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+
+	runner, err := NewBattleRunner(ConfigNopNano, []WarriorData{imp, dat})
+	require.NoError(t, err)
+
+	result, err := runner.Run(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, BattleResult{
+		Rounds: 5,
+		Wins:   []int{5, 0},
+		Losses: []int{0, 5},
+		Ties:   []int{0, 0},
+		Scores: []int{15, 0},
+	}, result)
+}
+
+// pspaceCounter counts its rounds in P-space and only survives the first 3
+const pspaceCounter = `
+start	ldp.ab	#1, count
+	add.ab	#1, count
+	stp.b	count, #1
+	slt.ab	#3, count
+	jmp	0
+	dat	0, 0
+count	dat	0, 0
+	end	start
+`
+
+func TestBattleRunnerPSpace(t *testing.T) {
+	config := ConfigNopNano
+	counter, err := CompileWarrior(strings.NewReader(pspaceCounter), config)
+	require.NoError(t, err)
+	stone := WarriorData{Code: []Instruction{{Op: JMP, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0}}}
+
+	runner, err := NewBattleRunner(config, []WarriorData{counter, stone})
+	require.NoError(t, err)
+	runner.SetSeed(5)
+
+	result, err := runner.Run(context.Background(), 8)
+	require.NoError(t, err)
+	assert.Equal(t, BattleResult{
+		Rounds: 8,
+		Wins:   []int{0, 5},
+		Losses: []int{5, 0},
+		Ties:   []int{3, 3},
+		Scores: []int{3, 18},
+	}, result)
+}
+
+func TestBattleRunnerWorkers(t *testing.T) {
+	config := ConfigNOP94
+	warriors := make([]WarriorData, 0)
+	for _, name := range []string{"warriors/94/simpleshot.red", "warriors/94/bombspiral.red"} {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, config)
+		require.NoError(t, err)
+		warriors = append(warriors, data)
+	}
+	counter, err := CompileWarrior(strings.NewReader(pspaceCounter), config)
+	require.NoError(t, err)
+
+	// rounds run in parallel give the same results and reach the reporters
+	// in round order, and battles using P-space are run on one simulator
+	for _, battle := range [][]WarriorData{warriors, {counter, warriors[0]}} {
+		var expected BattleResult
+		var expectedRounds [][]WarriorStats
+		for _, workers := range []int{1, 3, 8} {
+			runner, err := NewBattleRunner(config, battle)
+			require.NoError(t, err)
+			runner.SetSeed(99)
+			runner.SetWorkers(workers)
+			reporters := make([]*StatsReporter, 0)
+			runner.SetReporterFunc(func(sim ReportingSimulator) {
+				reporter := NewStatsReporter(sim)
+				sim.AddReporter(reporter)
+				reporters = append(reporters, reporter)
+			})
+
+			result, err := runner.Run(context.Background(), 20)
+			require.NoError(t, err)
+			rounds := make([][]WarriorStats, 0)
+			for _, reporter := range reporters {
+				rounds = append(rounds, reporter.Rounds()...)
+			}
+			require.Len(t, rounds, 20)
+
+			if workers == 1 {
+				expected = result
+				expectedRounds = rounds
+				continue
+			}
+			assert.Equal(t, expected, result)
+			assert.Equal(t, expectedRounds, rounds)
+			if battle[0].Code[0].Op == LDP {
+				assert.Len(t, reporters, 1)
+			} else {
+				assert.Len(t, reporters, workers)
+			}
+		}
+	}
+}
+
+func TestRunBattlesWorkers(t *testing.T) {
+	config := ConfigNOP94
+	warriors := make([]WarriorData, 0)
+	for _, name := range []string{"warriors/94/simpleshot.red", "warriors/94/bombspiral.red"} {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, config)
+		require.NoError(t, err)
+		warriors = append(warriors, data)
+	}
+	counter, err := CompileWarrior(strings.NewReader(pspaceCounter), config)
+	require.NoError(t, err)
+	warriors = append(warriors, counter)
+
+	var expected []BattleResult
+	for _, workers := range []int{1, 3, 8} {
+		runners := make([]*BattleRunner, 0)
+		for i := range warriors {
+			for j := range warriors {
+				if i == j {
+					continue
+				}
+				runner, err := NewBattleRunner(config, []WarriorData{warriors[i], warriors[j]})
+				require.NoError(t, err)
+				runner.SetSeed(int64(i*len(warriors) + j))
+				runners = append(runners, runner)
+			}
+		}
+
+		results, err := RunBattles(context.Background(), runners, 8, workers)
+		require.NoError(t, err)
+		if workers == 1 {
+			expected = results
+		} else {
+			assert.Equal(t, expected, results)
+		}
+	}
+	require.Len(t, expected, 6)
+
+	// the counter keeps its P-space over all 8 rounds of each battle, and
+	// only survives the first 3
+	for _, result := range []int{4, 5} {
+		assert.LessOrEqual(t, expected[result].Wins[0]+expected[result].Ties[0], 3)
+	}
+	for _, result := range []int{1, 3} {
+		assert.LessOrEqual(t, expected[result].Wins[1]+expected[result].Ties[1], 3)
+	}
+}
+
+func TestBattleRunnerCancel(t *testing.T) {
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	runner, err := NewBattleRunner(ConfigNOP94, []WarriorData{imp, imp})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runner.Run(ctx, 100)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBattleRunnerInvalid(t *testing.T) {
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+
+	_, err := NewBattleRunner(ConfigNOP94, nil)
+	require.Error(t, err)
+
+	runner, err := NewBattleRunner(ConfigNOP94, []WarriorData{imp, imp})
+	require.NoError(t, err)
+	_, err = runner.Run(context.Background(), 0)
+	require.Error(t, err)
+
+	runner.SetPlacement(func(round int) (Placement, error) {
+		return Placement{Offsets: []Address{0}}, nil
+	})
+	_, err = runner.Run(context.Background(), 1)
+	require.Error(t, err)
+
+	runner.SetPlacement(func(round int) (Placement, error) {
+		return Placement{Offsets: []Address{0, 200}, First: 2}, nil
+	})
+	_, err = runner.Run(context.Background(), 1)
+	require.Error(t, err)
+}
+
+func TestBattleRunnerSeed(t *testing.T) {
+	warriors := make([]WarriorData, 0)
+	for _, name := range []string{"warriors/94/simpleshot.red", "warriors/94/bombspiral.red"} {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, ConfigNOP94)
+		require.NoError(t, err)
+		warriors = append(warriors, data)
+	}
+
+	var expected BattleResult
+	for i := 0; i < 2; i++ {
+		runner, err := NewBattleRunner(ConfigNOP94, warriors)
+		require.NoError(t, err)
+		runner.SetSeed(1234)
+		assert.Equal(t, int64(1234), runner.Seed())
+
+		result, err := runner.Run(context.Background(), 8)
+		require.NoError(t, err)
+		if i == 0 {
+			expected = result
+		} else {
+			assert.Equal(t, expected, result)
+		}
+	}
+}
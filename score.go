@@ -0,0 +1,28 @@
+package gmars
+
+// RoundScores returns the points scored by each warrior in a round, given
+// which warriors survived. Surviving warriors score (W*W-1)/S points, where W
+// is the number of warriors and S is the number of survivors, following
+// pMARS. This is 3 points for a win and 1 for a tie in a two warrior battle.
+func RoundScores(alive []bool) []int {
+	scores := make([]int, len(alive))
+
+	survivors := 0
+	for _, a := range alive {
+		if a {
+			survivors++
+		}
+	}
+	if survivors == 0 {
+		return scores
+	}
+
+	n := len(alive)
+	points := (n*n - 1) / survivors
+	for i, a := range alive {
+		if a {
+			scores[i] = points
+		}
+	}
+	return scores
+}
@@ -0,0 +1,17 @@
+package gmars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundScores(t *testing.T) {
+	assert.Equal(t, []int{0}, RoundScores([]bool{true}))
+	assert.Equal(t, []int{3, 0}, RoundScores([]bool{true, false}))
+	assert.Equal(t, []int{1, 1}, RoundScores([]bool{true, true}))
+	assert.Equal(t, []int{0, 0}, RoundScores([]bool{false, false}))
+	assert.Equal(t, []int{0, 8, 0}, RoundScores([]bool{false, true, false}))
+	assert.Equal(t, []int{4, 0, 4}, RoundScores([]bool{true, false, true}))
+	assert.Equal(t, []int{2, 2, 2}, RoundScores([]bool{true, true, true}))
+}
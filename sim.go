@@ -10,6 +10,31 @@ const (
 	ICWS94
 )
 
+var simulatorModeNames = []string{
+	ICWS88: "ICWS88",
+	NOP94:  "NOP94",
+	ICWS94: "ICWS94",
+}
+
+// MarshalText encodes the simulator mode as its name
+func (m SimulatorMode) MarshalText() ([]byte, error) {
+	if int(m) >= len(simulatorModeNames) {
+		return nil, fmt.Errorf("invalid simulator mode: %d", m)
+	}
+	return []byte(simulatorModeNames[m]), nil
+}
+
+// UnmarshalText decodes a simulator mode from its name
+func (m *SimulatorMode) UnmarshalText(text []byte) error {
+	for i, name := range simulatorModeNames {
+		if name == string(text) {
+			*m = SimulatorMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid simulator mode: '%s'", text)
+}
+
 type SimulatorState uint8
 
 const (
@@ -25,7 +50,24 @@ type Simulator interface {
 	AddWarrior(data *WarriorData) (Warrior, error)
 	GetWarrior(wi int) Warrior
 	SpawnWarrior(wi int, startOffset Address) error
-	Run() []bool
+
+	// SetFirstWarrior sets the index of the warrior that runs first in each
+	// cycle. The other warriors follow in index order, wrapping around to 0.
+	// Reset sets the first warrior back to 0.
+	SetFirstWarrior(wi int) error
+
+	// Run runs the simulator until the round is over or a breakpoint is
+	// reached, and returns which warriors are alive and the breakpoint that
+	// stopped the simulator, if any. Calling Run again after a breakpoint
+	// continues from where it stopped, which may be partway through a cycle.
+	Run() ([]bool, *BreakEvent)
+
+	// AddBreakpoint registers a breakpoint checked by Run and returns an id
+	// that can be passed to RemoveBreakpoint. Breakpoints are not checked by
+	// RunCycle.
+	AddBreakpoint(b Breakpoint) (int, error)
+	RemoveBreakpoint(id int)
+	ClearBreakpoints()
 
 	// RunCycle runs a full cyle of the living warriors, starting at s,warriorIndex.
 	//
@@ -36,6 +78,13 @@ type Simulator interface {
 	GetMem(a Address) Instruction
 	Reset()
 
+	// Snapshot returns a copy of the complete state of the simulator, and
+	// Restore returns the simulator to the state held in a Snapshot. A
+	// Snapshot is only valid for simulators with the same configuration and
+	// warriors as the one it was taken from.
+	Snapshot() *Snapshot
+	Restore(snapshot *Snapshot) error
+
 	WarriorLivingCount() int
 	WarriorCount() int
 }
@@ -51,16 +100,30 @@ type reportSim struct {
 	maxCycles  Address
 	readLimit  Address
 	writeLimit Address
+	pspaceSize Address
+	foldReads  bool
+	foldWrites bool
 	mem        []Instruction
 	legacy     bool
 
 	warriors           []*warrior
 	reporters          []Reporter
+	reporterMasks      []ReportMask
+	reportMask         ReportMask
 	warriorIndex       int
+	firstWarrior       int
+	inCycle            bool
 	warriorCount       int
 	warriorLivingCount int
 
 	cycleCount Address
+
+	breakpoints    []breakpoint
+	nextBreakID    int
+	breakEvent     *BreakEvent
+	watchWrites    bool
+	skipCycleBreak bool
+	skipExecBreak  bool
 }
 
 func NewSimulator(config SimulatorConfig) (Simulator, error) {
@@ -83,6 +146,9 @@ func newReportSim(config SimulatorConfig) (*reportSim, error) {
 		maxCycles:  Address(config.Cycles),
 		readLimit:  Address(config.ReadLimit),
 		writeLimit: Address(config.WriteLimit),
+		pspaceSize: config.pspaceSize(),
+		foldReads:  config.ReadLimit != config.CoreSize,
+		foldWrites: config.WriteLimit != config.CoreSize,
 		legacy:     config.Mode == ICWS88,
 	}
 
@@ -104,11 +170,44 @@ func (s *reportSim) MaxCycles() int {
 }
 func (s *reportSim) AddReporter(r Reporter) {
 	s.reporters = append(s.reporters, r)
+	s.reporterMasks = append(s.reporterMasks, reporterMask(r))
+	s.updateReporting()
+}
+
+// updateReporting sets s.reportMask to the report types that need to be
+// sent, either to reporters or to check watchpoints
+func (s *reportSim) updateReporting() {
+	s.reportMask = 0
+	for _, mask := range s.reporterMasks {
+		s.reportMask |= mask
+	}
+	if s.watchWrites {
+		s.reportMask |= NewReportMask(WarriorWrite, WarriorIncrement, WarriorDecrement)
+	}
+}
+
+// wants returns true if reports of type t need to be sent
+func (s *reportSim) wants(t ReportType) bool {
+	return s.reportMask.Has(t)
 }
 
+// Report sends a report to the reporters. It is kept small enough to be
+// inlined so that building and sending reports costs a single branch when
+// nothing is listening for the report type.
 func (s *reportSim) Report(report Report) {
-	for _, r := range s.reporters {
-		r.Report(report)
+	if s.wants(report.Type) {
+		s.sendReport(report)
+	}
+}
+
+func (s *reportSim) sendReport(report Report) {
+	if s.watchWrites {
+		s.checkWriteBreak(report)
+	}
+	for i, r := range s.reporters {
+		if s.reporterMasks[i].Has(report.Type) {
+			r.Report(report)
+		}
 	}
 }
 
@@ -131,10 +230,21 @@ func (s *reportSim) AddWarrior(data *WarriorData) (Warrior, error) {
 }
 
 func (s *reportSim) addWarrior(data *WarriorData) (*warrior, error) {
+	if s.legacy {
+		for _, inst := range data.Code {
+			if inst.Op == LDP || inst.Op == STP {
+				return nil, fmt.Errorf("%s is not supported in ICWS88 mode", inst.Op)
+			}
+		}
+	}
+
 	w := &warrior{
-		data: data.Copy(),
-		sim:  s,
+		data:   data.Copy(),
+		sim:    s,
+		pspace: make([]Address, s.pspaceSize),
 	}
+	// the result of the last round is -1 before the first round
+	w.pspace[0] = s.m - 1
 	w.index = len(s.warriors)
 	s.warriors = append(s.warriors, w)
 	s.warriorCount += 1
@@ -165,8 +275,16 @@ func (s *reportSim) spawnWarrior(wi int, startOffset Address) error {
 	w.state = WarriorAlive
 	s.warriorLivingCount += 1
 
-	s.Report(Report{Type: WarriorSpawn, WarriorIndex: w.index, Address: startOffset})
+	s.Report(Report{Type: WarriorSpawn, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: startOffset})
+
+	return nil
+}
 
+func (s *reportSim) SetFirstWarrior(wi int) error {
+	if wi < 0 || wi >= s.warriorCount {
+		return fmt.Errorf("warrior index out of bounds")
+	}
+	s.firstWarrior = wi
 	return nil
 }
 
@@ -179,17 +297,49 @@ func (s *reportSim) WarriorLivingCount() int {
 }
 
 func (s *reportSim) RunCycle() int {
+	return s.runCycle(false)
+}
+
+// runCycle runs the rest of the current cycle. If checkBreaks is true, it
+// stops at the first breakpoint reached and stores it in s.breakEvent,
+// leaving s.warriorIndex at the next warrior to run.
+func (s *reportSim) runCycle(checkBreaks bool) int {
 	if s.cycleCount >= s.maxCycles || s.warriorLivingCount < 1 {
 		return 0
 	}
 
-	if s.warriorIndex == 0 {
+	if !s.inCycle {
+		if checkBreaks && !s.skipCycleBreak {
+			s.checkCycleBreak()
+			if s.breakEvent != nil {
+				s.skipCycleBreak = true
+				return s.warriorLivingCount
+			}
+		}
+		s.skipCycleBreak = false
+		s.inCycle = true
 		s.Report(Report{Type: CycleStart, Cycle: int(s.cycleCount)})
 	}
 
-	// step through s.warriors from s.warriorIndex to the end
-	for i := s.warriorIndex; i < s.warriorCount; i++ {
+	// step through s.warriors from s.warriorIndex to the end, starting from
+	// s.firstWarrior and wrapping around
+	for k := s.warriorIndex; k < s.warriorCount; k++ {
+		i := (k + s.firstWarrior) % s.warriorCount
 		if s.warriors[i].state == WarriorAlive {
+			if checkBreaks && !s.skipExecBreak {
+				next, err := s.warriors[i].pq.Next()
+				if err == nil {
+					s.checkExecBreak(i, next)
+				}
+				if s.breakEvent != nil {
+					s.warriorIndex = k
+					s.skipExecBreak = true
+					return s.warriorLivingCount
+				}
+			}
+			s.skipExecBreak = false
+			before := s.warriors[i].pq.Len()
+
 			// I don't like this, and this should never happen, but we will
 			// silently reap any zombie warriors here that are 'alive' without
 			// a process queue so we can continue and check the next ones.
@@ -200,9 +350,21 @@ func (s *reportSim) RunCycle() int {
 				continue
 			}
 
-			s.Report(Report{Type: WarriorTaskPop, Cycle: int(s.cycleCount), WarriorIndex: i, Address: pc})
+			if s.wants(WarriorTaskPop) {
+				s.sendReport(Report{
+					Type:         WarriorTaskPop,
+					Cycle:        int(s.cycleCount),
+					WarriorIndex: i,
+					Address:      pc,
+					Instruction:  s.mem[pc],
+					QueueLen:     int(s.warriors[i].pq.Len()),
+				})
+			}
 
 			s.exec(pc, s.warriors[i])
+			if checkBreaks {
+				s.checkProcessBreak(i, before, s.warriors[i].pq.Len())
+			}
 			if s.warriors[i].pq.Len() == 0 {
 				s.Report(Report{Type: WarriorTerminate, Cycle: int(s.cycleCount), WarriorIndex: i, Address: pc})
 				s.warriors[i].state = WarriorDead
@@ -214,18 +376,32 @@ func (s *reportSim) RunCycle() int {
 					}
 				}
 			}
+
+			// stop after this warrior if it hit a breakpoint, unless it
+			// was the last warrior of the cycle
+			if s.breakEvent != nil && k+1 < s.warriorCount {
+				s.warriorIndex = k + 1
+				return s.warriorLivingCount
+			}
 		}
 	}
 
 	s.Report(Report{Type: CycleEnd, Cycle: int(s.cycleCount)})
 
 	s.warriorIndex = 0
+	s.inCycle = false
 	s.cycleCount++
 
 	return s.warriorLivingCount
 }
 
+// readFold folds pointer into the read limit. Folded pointers are always
+// added to the PC modulo the core size, so when there is no read limit the
+// pointer can be returned as it is.
 func (s *reportSim) readFold(pointer Address) Address {
+	if !s.foldReads {
+		return pointer
+	}
 	res := pointer % s.readLimit
 	if res > (s.readLimit / 2) {
 		res += (s.m - s.readLimit)
@@ -233,7 +409,11 @@ func (s *reportSim) readFold(pointer Address) Address {
 	return res
 }
 
+// writeFold folds pointer into the write limit, see readFold
 func (s *reportSim) writeFold(pointer Address) Address {
+	if !s.foldWrites {
+		return pointer
+	}
 	res := pointer % s.writeLimit
 	if res > (s.writeLimit / 2) {
 		res += (s.m - s.writeLimit)
@@ -261,14 +441,20 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 		if IR.AMode == A_INDIRECT || IR.AMode == A_DECREMENT || IR.AMode == A_INCREMENT {
 			if IR.AMode == A_DECREMENT {
 				dptr := (PC + WPA) % s.m
+				before := s.mem[dptr]
 				s.mem[dptr].A = (s.mem[dptr].A + s.m - 1) % s.m
-				s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: dptr})
+				if s.wants(WarriorDecrement) {
+					s.reportChange(WarriorDecrement, w, dptr, before)
+				}
 			}
 
 			if IR.AMode == A_INCREMENT {
 				PIP = (PC + WPA) % s.m
 			}
 
+			if s.wants(WarriorRead) {
+				s.reportRead(w, (PC+RPA)%s.m, s.mem[(PC+RPA)%s.m])
+			}
 			RPA = s.readFold(RPA + s.mem[(PC+RPA)%s.m].A)
 			// not used, but should be updated here if it were to be
 			// WPA = s.writeFold(WPA + s.mem[(PC+WPA)%s.m].A)
@@ -277,14 +463,20 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 		if IR.AMode == B_INDIRECT || IR.AMode == B_DECREMENT || IR.AMode == B_INCREMENT {
 			if IR.AMode == B_DECREMENT {
 				dptr := (PC + WPA) % s.m
+				before := s.mem[dptr]
 				s.mem[dptr].B = (s.mem[dptr].B + s.m - 1) % s.m
-				s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: dptr})
+				if s.wants(WarriorDecrement) {
+					s.reportChange(WarriorDecrement, w, dptr, before)
+				}
 			}
 
 			if IR.AMode == B_INCREMENT {
 				PIP = (PC + WPA) % s.m
 			}
 
+			if s.wants(WarriorRead) {
+				s.reportRead(w, (PC+RPA)%s.m, s.mem[(PC+RPA)%s.m])
+			}
 			RPA = s.readFold(RPA + s.mem[(PC+RPA)%s.m].B)
 			// not used, but should be updated here if it were to be
 			// WPA = s.writeFold(WPA + s.mem[(PC+WPA)%s.m].B)
@@ -297,10 +489,17 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 
 	// do post-increments, if needed, after IRA has been assigned
 	if IR.AMode == A_INCREMENT {
+		before := s.mem[PIP]
 		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m
-	}
-	if IR.AMode == B_INCREMENT {
+		if s.wants(WarriorIncrement) {
+			s.reportChange(WarriorIncrement, w, PIP, before)
+		}
+	} else if IR.AMode == B_INCREMENT {
+		before := s.mem[PIP]
 		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m
+		if s.wants(WarriorIncrement) {
+			s.reportChange(WarriorIncrement, w, PIP, before)
+		}
 	}
 
 	// prepare B indirect references and decrement or save increment pointer
@@ -311,13 +510,20 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 		if IR.BMode == A_INDIRECT || IR.BMode == A_DECREMENT || IR.BMode == A_INCREMENT {
 			if IR.BMode == A_DECREMENT {
 				dptr := (PC + WPB) % s.m
+				before := s.mem[dptr]
 				s.mem[dptr].A = (s.mem[dptr].A + s.m - 1) % s.m
+				if s.wants(WarriorDecrement) {
+					s.reportChange(WarriorDecrement, w, dptr, before)
+				}
 			}
 
 			if IR.BMode == A_INCREMENT {
 				PIP = (PC + WPB) % s.m
 			}
 
+			if s.wants(WarriorRead) {
+				s.reportRead(w, (PC+RPB)%s.m, s.mem[(PC+RPB)%s.m])
+			}
 			RPB = s.readFold(RPB + s.mem[(PC+RPB)%s.m].A)
 			WPB = s.writeFold(WPB + s.mem[(PC+WPB)%s.m].A)
 		}
@@ -325,13 +531,20 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 		if IR.BMode == B_INDIRECT || IR.BMode == B_DECREMENT || IR.BMode == B_INCREMENT {
 			if IR.BMode == B_DECREMENT {
 				dptr := (PC + WPB) % s.m
+				before := s.mem[dptr]
 				s.mem[dptr].B = (s.mem[dptr].B + s.m - 1) % s.m
+				if s.wants(WarriorDecrement) {
+					s.reportChange(WarriorDecrement, w, dptr, before)
+				}
 			}
 
 			if IR.BMode == B_INCREMENT {
 				PIP = (PC + WPB) % s.m
 			}
 
+			if s.wants(WarriorRead) {
+				s.reportRead(w, (PC+RPB)%s.m, s.mem[(PC+RPB)%s.m])
+			}
 			RPB = s.readFold(RPB + s.mem[(PC+RPB)%s.m].B)
 			WPB = s.writeFold(WPB + s.mem[(PC+WPB)%s.m].B)
 		}
@@ -343,83 +556,185 @@ func (s *reportSim) exec(PC Address, w *warrior) {
 
 	// do post-increments, if needed, after IRB has been assigned
 	if IR.BMode == A_INCREMENT {
+		before := s.mem[PIP]
 		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m
-		s.Report(Report{Type: WarriorIncrement, WarriorIndex: w.index, Address: PIP})
+		if s.wants(WarriorIncrement) {
+			s.reportChange(WarriorIncrement, w, PIP, before)
+		}
 	} else if IR.BMode == B_INCREMENT {
+		before := s.mem[PIP]
 		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m
-		s.Report(Report{Type: WarriorIncrement, WarriorIndex: w.index, Address: PIP})
+		if s.wants(WarriorIncrement) {
+			s.reportChange(WarriorIncrement, w, PIP, before)
+		}
+	}
+
+	// report reads of the operand values used by the instruction
+	if s.wants(WarriorRead) {
+		readsA, readsB := operandReads(IR.Op)
+		if readsA {
+			s.reportRead(w, (PC+RPA)%s.m, IRA)
+		}
+		if readsB {
+			s.reportRead(w, (PC+RPB)%s.m, IRB)
+		}
 	}
 
 	WAB := (PC + WPB) % s.m
 	RAB := (PC + RPA) % s.m
 
+	// save the contents of the B-target to report its value before writes
+	var before Instruction
+	if s.wants(WarriorWrite) || s.wants(WarriorDecrement) {
+		before = s.mem[WAB]
+	}
+
 	switch IR.Op {
 	case DAT:
-		s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+		s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 		return
 	case MOV:
 		s.mov(IR, IRA, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case ADD:
 		s.add(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case SUB:
 		s.sub(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case MUL:
 		s.mul(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case DIV:
 		s.div(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case MOD:
 		s.mod(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
 	case JMP:
-		w.pq.Push(RAB)
+		s.push(w, RAB)
 	case JMZ:
 		s.jmz(IR, IRB, RAB, PC, w)
 	case JMN:
 		s.jmn(IR, IRB, RAB, PC, w)
 	case DJN:
 		s.djn(IR, IRB, RAB, WAB, PC, w)
-		s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: WAB})
+		if s.wants(WarriorDecrement) {
+			s.reportChange(WarriorDecrement, w, WAB, before)
+		}
 	case CMP:
 		fallthrough
 	case SEQ:
 		s.cmp(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SLT:
 		s.slt(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SNE:
 		s.sne(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorRead, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SPL:
-		w.pq.Push((PC + 1) % s.m)
-		w.pq.Push(RAB)
+		s.push(w, (PC+1)%s.m)
+		s.push(w, RAB)
 	case NOP:
-		w.pq.Push((PC + 1) % s.m)
+		s.push(w, (PC+1)%s.m)
+	case LDP:
+		s.ldp(IR, IRA, WAB, PC, w)
+		if s.wants(WarriorWrite) {
+			s.reportChange(WarriorWrite, w, WAB, before)
+		}
+	case STP:
+		index, before := s.stp(IR, IRA, IRB, PC, w)
+		if s.wants(WarriorPSpaceWrite) {
+			s.sendReport(Report{
+				Type:         WarriorPSpaceWrite,
+				Cycle:        int(s.cycleCount),
+				WarriorIndex: w.index,
+				Address:      index,
+				Instruction:  Instruction{B: w.pspace[index]},
+				Before:       Instruction{B: before},
+			})
+		}
+	}
+}
+
+// reportChange reports a change of type t made by w to the instruction at a,
+// which held before prior to the change. Callers check s.wants(t) first to
+// avoid the call when nothing is listening.
+func (s *reportSim) reportChange(t ReportType, w *warrior, a Address, before Instruction) {
+	s.sendReport(Report{
+		Type:         t,
+		Cycle:        int(s.cycleCount),
+		WarriorIndex: w.index,
+		Address:      a,
+		Instruction:  s.mem[a],
+		Before:       before,
+	})
+}
+
+// operandReads returns whether op uses the values of the instructions
+// referenced by its A and B operands
+func operandReads(op OpCode) (bool, bool) {
+	switch op {
+	case MOV, LDP:
+		return true, false
+	case ADD, SUB, MUL, DIV, MOD, CMP, SEQ, SNE, SLT, STP:
+		return true, true
+	case JMZ, JMN, DJN:
+		return false, true
 	}
+	return false, false
+}
+
+// reportRead reports w reading the instruction inst from address a
+func (s *reportSim) reportRead(w *warrior, a Address, inst Instruction) {
+	s.sendReport(Report{
+		Type:         WarriorRead,
+		Cycle:        int(s.cycleCount),
+		WarriorIndex: w.index,
+		Address:      a,
+		Instruction:  inst,
+	})
 }
 
 // Run runs the simulator until the max cycles are reached, one warrior
 // remains in a battle with more than one warrior, or the only warrior
 // dies in a single warrior battle
-func (s *reportSim) Run() []bool {
+func (s *reportSim) Run() ([]bool, *BreakEvent) {
 	nWarriors := len(s.warriors)
 
 	// if no warriors are loaded, return nil
 	if nWarriors == 0 {
-		return nil
+		return nil, nil
+	}
+
+	checkBreaks := len(s.breakpoints) > 0
+	for _, b := range s.breakpoints {
+		if b.Type == BreakWrite {
+			s.watchWrites = true
+		}
 	}
+	s.updateReporting()
+	s.breakEvent = nil
 
 	// run until simulation
+	var event *BreakEvent
 	for s.cycleCount < s.maxCycles {
-		aliveCount := s.RunCycle()
+		aliveCount := s.runCycle(checkBreaks)
+
+		if s.breakEvent != nil {
+			event = s.breakEvent
+			break
+		}
 
 		if nWarriors == 1 && aliveCount == 0 {
 			break
@@ -427,26 +742,51 @@ func (s *reportSim) Run() []bool {
 			break
 		}
 	}
+	s.watchWrites = false
+	s.updateReporting()
+	s.breakEvent = nil
 
 	// collect and return results
 	result := make([]bool, nWarriors)
 	for i, warrior := range s.warriors {
 		result[i] = warrior.Alive()
 	}
-	return result
+	return result, event
 }
 
 func (s *reportSim) GetMem(a Address) Instruction {
 	return s.mem[a%s.m]
 }
 
+// recordResults stores the outcome of the last round in P-space location 0
+// of each warrior: 0 if the warrior died, or the number of surviving warriors.
+func (s *reportSim) recordResults() {
+	for _, warrior := range s.warriors {
+		if warrior.state == WarriorAlive {
+			warrior.pspace[0] = Address(s.warriorLivingCount) % s.m
+		} else if warrior.state == WarriorDead {
+			warrior.pspace[0] = 0
+		}
+	}
+}
+
+// Reset clears the core and warrior states to prepare for another round.
+// Warrior P-space is preserved and the results of the previous round are
+// recorded in P-space location 0.
 func (s *reportSim) Reset() {
 	s.Report(Report{Type: SimReset})
 
+	s.recordResults()
+
 	for _, warrior := range s.warriors {
 		warrior.state = WarriorAdded
 	}
 	s.mem = make([]Instruction, s.m)
 	s.cycleCount = 0
+	s.warriorIndex = 0
+	s.firstWarrior = 0
+	s.inCycle = false
+	s.skipCycleBreak = false
+	s.skipExecBreak = false
 	s.warriorLivingCount = 0
 }
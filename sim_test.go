@@ -2,6 +2,7 @@ package gmars
 
 import (
 	"bytes"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -58,7 +59,7 @@ func TestRunImp(t *testing.T) {
 	err = sim.SpawnWarrior(0, 0)
 	require.NoError(t, err)
 
-	state := sim.Run()
+	state, _ := sim.Run()
 	require.Equal(t, 1, len(state))
 	require.True(t, state[0])
 	require.True(t, w.Alive())
@@ -82,7 +83,7 @@ func TestRunTwoImps(t *testing.T) {
 	err = sim.SpawnWarrior(1, 200)
 	require.NoError(t, err)
 
-	state := sim.Run()
+	state, _ := sim.Run()
 	require.Equal(t, 2, len(state))
 	require.True(t, state[0])
 	require.True(t, state[1])
@@ -90,3 +91,151 @@ func TestRunTwoImps(t *testing.T) {
 	require.True(t, w2.Alive())
 	require.Equal(t, 80000, sim.CycleCount())
 }
+
+func TestAddWarriorPSpace88(t *testing.T) {
+	sim, err := NewSimulator(ConfigICWS88)
+	require.NoError(t, err)
+
+	for _, op := range []OpCode{LDP, STP} {
+		data := WarriorData{Code: []Instruction{{Op: op, OpMode: B, AMode: IMMEDIATE, A: 0, BMode: DIRECT, B: 1}}}
+		_, err = sim.AddWarrior(&data)
+		require.Error(t, err, op)
+	}
+}
+
+func TestPSpaceResults(t *testing.T) {
+	config := ConfigNOP94
+
+	sim, err := newReportSim(config)
+	require.NoError(t, err)
+
+	imp := &WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	dat := &WarriorData{Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+
+	w1, err := sim.addWarrior(imp)
+	require.NoError(t, err)
+	w2, err := sim.addWarrior(dat)
+	require.NoError(t, err)
+	require.Equal(t, Address(500), Address(len(w1.pspace)))
+	require.Equal(t, config.CoreSize-1, w1.pspace[0])
+	require.Equal(t, config.CoreSize-1, w2.pspace[0])
+
+	// imp wins, dat loses
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	require.NoError(t, sim.SpawnWarrior(1, 4000))
+	sim.Run()
+	sim.Reset()
+	require.Equal(t, Address(1), w1.pspace[0])
+	require.Equal(t, Address(0), w2.pspace[0])
+
+	// values other than the result are preserved between rounds
+	w1.pspace[10] = 123
+
+	// two imps tie
+	w2.data = imp
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	require.NoError(t, sim.SpawnWarrior(1, 4000))
+	sim.Run()
+	sim.Reset()
+	require.Equal(t, Address(2), w1.pspace[0])
+	require.Equal(t, Address(2), w2.pspace[0])
+	require.Equal(t, Address(123), w1.pspace[10])
+}
+
+// popRecorder records the warrior index of each task pop
+type popRecorder struct {
+	warriors []int
+}
+
+func (r *popRecorder) Report(report Report) {
+	if report.Type == WarriorTaskPop {
+		r.warriors = append(r.warriors, report.WarriorIndex)
+	}
+}
+
+func TestSetFirstWarrior(t *testing.T) {
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	rec := &popRecorder{}
+	sim.AddReporter(rec)
+	for i := 0; i < 3; i++ {
+		_, err = sim.AddWarrior(&imp)
+		require.NoError(t, err)
+		err = sim.SpawnWarrior(i, Address(i*1000))
+		require.NoError(t, err)
+	}
+
+	require.Error(t, sim.SetFirstWarrior(3))
+	require.Error(t, sim.SetFirstWarrior(-1))
+
+	require.NoError(t, sim.SetFirstWarrior(1))
+	sim.RunCycle()
+	sim.RunCycle()
+	require.Equal(t, []int{1, 2, 0, 1, 2, 0}, rec.warriors)
+
+	// reset runs warrior 0 first again
+	sim.Reset()
+	rec.warriors = nil
+	for i := 0; i < 3; i++ {
+		err = sim.SpawnWarrior(i, Address(i*1000))
+		require.NoError(t, err)
+	}
+	sim.RunCycle()
+	require.Equal(t, []int{0, 1, 2}, rec.warriors)
+}
+
+func TestReportingEquivalence(t *testing.T) {
+	limited := ConfigNOP94
+	limited.ReadLimit = 500
+	limited.WriteLimit = 250
+
+	pairs := [][]string{
+		{"warriors/94/simpleshot.red", "warriors/94/bombspiral.red"},
+		{"warriors/94/paperhaze.red", "warriors/94/scaryvampire.red"},
+	}
+
+	for _, config := range []SimulatorConfig{ConfigNOP94, limited} {
+		for _, pair := range pairs {
+			warriors := make([]WarriorData, 0)
+			for _, name := range pair {
+				in, err := os.Open(name)
+				require.NoError(t, err)
+				data, err := CompileWarrior(in, config)
+				in.Close()
+				require.NoError(t, err)
+				warriors = append(warriors, data)
+			}
+
+			fast, err := NewSimulator(config)
+			require.NoError(t, err)
+			reporting, err := NewReportingSimulator(config)
+			require.NoError(t, err)
+			reporting.AddReporter(NewStateRecorder(reporting))
+
+			placement := SeededPlacement(config, 2, 5)
+			for round := 0; round < 5; round++ {
+				p, err := placement(round)
+				require.NoError(t, err)
+				for _, sim := range []Simulator{fast, reporting} {
+					if round > 0 {
+						sim.Reset()
+					}
+					for i := range warriors {
+						if round == 0 {
+							_, err := sim.AddWarrior(&warriors[i])
+							require.NoError(t, err)
+						}
+						require.NoError(t, sim.SpawnWarrior(i, p.Offsets[i]))
+					}
+				}
+
+				fastResult, _ := fast.Run()
+				reportingResult, _ := reporting.Run()
+				require.Equal(t, reportingResult, fastResult)
+				require.Equal(t, reporting.Snapshot(), fast.Snapshot())
+			}
+		}
+	}
+}
@@ -1,5 +1,32 @@
 package gmars
 
+// pushReports is the mask of report types sent by push
+const pushReports = ReportMask(1<<WarriorTaskPush | 1<<WarriorProcessLimit)
+
+// push adds a to the process queue of w and reports the push. If the queue
+// is already at the process limit, the process is dropped and reported as
+// WarriorProcessLimit instead.
+func (s *reportSim) push(w *warrior, a Address) {
+	pushed := w.pq.Push(a)
+	if s.reportMask&pushReports != 0 {
+		s.reportPush(w, a, pushed)
+	}
+}
+
+func (s *reportSim) reportPush(w *warrior, a Address, pushed bool) {
+	t := WarriorTaskPush
+	if !pushed {
+		t = WarriorProcessLimit
+	}
+	s.Report(Report{
+		Type:         t,
+		Cycle:        int(s.cycleCount),
+		WarriorIndex: w.index,
+		Address:      a,
+		QueueLen:     int(w.pq.Len()),
+	})
+}
+
 func (s *reportSim) mov(IR, IRA Instruction, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
@@ -20,8 +47,7 @@ func (s *reportSim) mov(IR, IRA Instruction, WAB, PC Address, w *warrior) {
 		s.mem[WAB] = IRA
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) add(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
@@ -44,8 +70,7 @@ func (s *reportSim) add(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 		s.mem[WAB].B = (IRB.B + IRA.A) % s.m
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) sub(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
@@ -68,8 +93,7 @@ func (s *reportSim) sub(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 		s.mem[WAB].B = (IRB.B + (s.m - IRA.A)) % s.m
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) mul(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
@@ -92,8 +116,7 @@ func (s *reportSim) mul(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 		s.mem[WAB].B = (IRB.B * IRA.A) % s.m
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
@@ -102,28 +125,28 @@ func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 		if IRA.A != 0 {
 			s.mem[WAB].A = IRB.A / IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case B:
 		if IRA.B != 0 {
 			s.mem[WAB].B = IRB.B / IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case AB:
 		if IRA.A != 0 {
 			s.mem[WAB].B = IRB.B / IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case BA:
 		if IRA.B != 0 {
 			s.mem[WAB].A = IRB.A / IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case F:
@@ -136,7 +159,7 @@ func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].B = IRB.B / IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case X:
@@ -147,13 +170,12 @@ func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].A = IRB.A / IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
@@ -162,28 +184,28 @@ func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 		if IRA.A != 0 {
 			s.mem[WAB].A = IRB.A % IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case B:
 		if IRA.B != 0 {
 			s.mem[WAB].B = IRB.B % IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case AB:
 		if IRA.A != 0 {
 			s.mem[WAB].B = IRB.B % IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case BA:
 		if IRA.B != 0 {
 			s.mem[WAB].A = IRB.A % IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case F:
@@ -196,7 +218,7 @@ func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].B = IRB.B % IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	case X:
@@ -207,13 +229,12 @@ func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].A = IRB.A % IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC})
 			return
 		}
 	}
 	nextPC := (PC + 1) % s.m
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) jmz(IR, IRB Instruction, RAB, PC Address, w *warrior) {
@@ -222,17 +243,17 @@ func (s *reportSim) jmz(IR, IRB Instruction, RAB, PC Address, w *warrior) {
 		fallthrough
 	case BA:
 		if IRB.A == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		fallthrough
 	case AB:
 		if IRB.B == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		fallthrough
@@ -240,9 +261,9 @@ func (s *reportSim) jmz(IR, IRB Instruction, RAB, PC Address, w *warrior) {
 		fallthrough
 	case I:
 		if IRB.A == 0 && IRB.B == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	}
 }
@@ -274,8 +295,7 @@ func (s *reportSim) jmn(IR, IRB Instruction, RAB, PC Address, w *warrior) {
 		return
 	}
 
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) djn(IR, IRB Instruction, RAB, WAB, PC Address, w *warrior) {
@@ -311,8 +331,7 @@ func (s *reportSim) djn(IR, IRB Instruction, RAB, WAB, PC Address, w *warrior) {
 			nextPC = RAB
 		}
 	}
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) cmp(IR, IRA, IRB Instruction, PC Address, w *warrior) {
@@ -349,8 +368,7 @@ func (s *reportSim) cmp(IR, IRA, IRB Instruction, PC Address, w *warrior) {
 			nextPC = (PC + 2) % s.m
 		}
 	}
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) sne(IR, IRA, IRB Instruction, PC Address, w *warrior) {
@@ -388,8 +406,7 @@ func (s *reportSim) sne(IR, IRA, IRB Instruction, PC Address, w *warrior) {
 			nextPC = (PC + 2) % s.m
 		}
 	}
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
 }
 
 func (s *reportSim) slt(IR, IRA, IRB Instruction, PC Address, w *warrior) {
@@ -423,6 +440,54 @@ func (s *reportSim) slt(IR, IRA, IRB Instruction, PC Address, w *warrior) {
 			nextPC = (PC + 2) % s.m
 		}
 	}
-	s.Report(Report{Type: WarriorTaskPush, WarriorIndex: w.index, Address: nextPC})
-	w.pq.Push(nextPC)
+	s.push(w, nextPC)
+}
+
+func (s *reportSim) ldp(IR, IRA Instruction, WAB, PC Address, w *warrior) {
+	switch IR.OpMode {
+	case A:
+		s.mem[WAB].A = w.pspace[IRA.A%s.pspaceSize]
+	case B:
+		fallthrough
+	case F:
+		fallthrough
+	case X:
+		fallthrough
+	case I:
+		s.mem[WAB].B = w.pspace[IRA.B%s.pspaceSize]
+	case AB:
+		s.mem[WAB].B = w.pspace[IRA.A%s.pspaceSize]
+	case BA:
+		s.mem[WAB].A = w.pspace[IRA.B%s.pspaceSize]
+	}
+	nextPC := (PC + 1) % s.m
+	s.push(w, nextPC)
+}
+
+// stp stores a value in the P-space of w and returns the index written and
+// the value it replaced
+func (s *reportSim) stp(IR, IRA, IRB Instruction, PC Address, w *warrior) (Address, Address) {
+	var index, value Address
+	switch IR.OpMode {
+	case A:
+		index, value = IRB.A, IRA.A
+	case B:
+		fallthrough
+	case F:
+		fallthrough
+	case X:
+		fallthrough
+	case I:
+		index, value = IRB.B, IRA.B
+	case AB:
+		index, value = IRB.B, IRA.A
+	case BA:
+		index, value = IRB.A, IRA.B
+	}
+	index %= s.pspaceSize
+	before := w.pspace[index]
+	w.pspace[index] = value
+	nextPC := (PC + 1) % s.m
+	s.push(w, nextPC)
+	return index, before
 }
@@ -1725,3 +1725,120 @@ func TestNOP(t *testing.T) {
 	}
 	runTests(t, "nop", tests)
 }
+
+type pspaceTest struct {
+	input  []string
+	output []string
+	pspace []Address
+	pq     []Address
+}
+
+func runPSpaceTests(t *testing.T, set_name string, tests []pspaceTest) {
+	for i, test := range tests {
+		coresize := Address(100)
+
+		code := make([]Instruction, len(test.input))
+		for j, instring := range test.input {
+			code[j] = parseTestInstruction(t, instring, int(coresize))
+		}
+
+		config := NewQuickConfig(NOP94, coresize, coresize, 1, coresize)
+		config.Distance = 0
+		config.PSpaceSize = Address(len(test.pspace))
+
+		sim, err := newReportSim(config)
+		require.NoError(t, err)
+		w, err := sim.addWarrior(&WarriorData{Code: code})
+		require.NoError(t, err)
+		for j := 1; j < len(test.pspace); j++ {
+			w.pspace[j] = Address(j * 10)
+		}
+		err = sim.spawnWarrior(0, 0)
+		require.NoError(t, err)
+
+		sim.RunCycle()
+
+		for j, instring := range test.output {
+			expected := parseTestInstruction(t, instring, int(coresize))
+			assert.Equal(t, expected, sim.GetMem(Address(j)), fmt.Sprintf("%s test %d address %d", set_name, i, j))
+		}
+		assert.Equal(t, test.pspace, w.pspace, fmt.Sprintf("%s test %d pspace", set_name, i))
+		assert.Equal(t, test.pq, w.pq.Values(), fmt.Sprintf("%s test %d", set_name, i))
+	}
+}
+
+func TestLDP(t *testing.T) {
+	// p-space values are initialized to 10 * index, and -1 at index 0 in a
+	// core of size 100
+	tests := []pspaceTest{
+		{
+			input:  []string{"ldp.a $1, $2", "dat.f $2, $3", "dat.f $0, $0"},
+			output: []string{"ldp.a $1, $2", "dat.f $2, $3", "dat.f $20, $0", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"ldp.b $1, $2", "dat.f $2, $3", "dat.f $0, $0"},
+			output: []string{"ldp.b $1, $2", "dat.f $2, $3", "dat.f $0, $30", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"ldp.ab #2, $2", "dat.f $0, $0", "dat.f $0, $0"},
+			output: []string{"ldp.ab #2, $2", "dat.f $0, $0", "dat.f $0, $20", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"ldp.ba $1, $2", "dat.f $2, $1", "dat.f $0, $0"},
+			output: []string{"ldp.ba $1, $2", "dat.f $2, $1", "dat.f $10, $0", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+		// index is taken modulo the p-space size
+		{
+			input:  []string{"ldp.ab #5, $2", "dat.f $0, $0", "dat.f $0, $0"},
+			output: []string{"ldp.ab #5, $2", "dat.f $0, $0", "dat.f $0, $10", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+		// location 0 holds the last result, -1 before the first round
+		{
+			input:  []string{"ldp.ab #0, $2", "dat.f $0, $0", "dat.f $0, $0"},
+			output: []string{"ldp.ab #0, $2", "dat.f $0, $0", "dat.f $0, $-1", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 30},
+			pq:     []Address{1},
+		},
+	}
+	runPSpaceTests(t, "ldp", tests)
+}
+
+func TestSTP(t *testing.T) {
+	tests := []pspaceTest{
+		{
+			input:  []string{"stp.a $1, $2", "dat.f $2, $3", "dat.f $1, $0"},
+			output: []string{"stp.a $1, $2", "dat.f $2, $3", "dat.f $1, $0", "dat.f $0, $0"},
+			pspace: []Address{99, 2, 20, 30},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"stp.b $1, $2", "dat.f $2, $3", "dat.f $0, $2"},
+			output: []string{"stp.b $1, $2", "dat.f $2, $3", "dat.f $0, $2", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 3, 30},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"stp.ab #1, #3", "dat.f $0, $0", "dat.f $0, $0"},
+			output: []string{"stp.ab #1, #3", "dat.f $0, $0", "dat.f $0, $0", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 20, 1},
+			pq:     []Address{1},
+		},
+		{
+			input:  []string{"stp.ba $1, $2", "dat.f $0, $3", "dat.f $2, $0"},
+			output: []string{"stp.ba $1, $2", "dat.f $0, $3", "dat.f $2, $0", "dat.f $0, $0"},
+			pspace: []Address{99, 10, 3, 30},
+			pq:     []Address{1},
+		},
+	}
+	runPSpaceTests(t, "stp", tests)
+}
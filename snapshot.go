@@ -0,0 +1,312 @@
+package gmars
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	snapshotMagic   = "GMSS"
+	snapshotVersion = 2
+)
+
+// Snapshot holds the complete state of a Simulator. InCycle and WarriorIndex
+// record the position within a cycle when Run was stopped by a breakpoint.
+// A Snapshot can be restored into the Simulator it was taken from, or any
+// other Simulator with the same configuration and warriors.
+type Snapshot struct {
+	Mem          []Instruction
+	CycleCount   int
+	WarriorIndex int
+	FirstWarrior int
+	InCycle      bool
+	Warriors     []WarriorSnapshot
+}
+
+// WarriorSnapshot holds the state of a single warrior in a Snapshot
+type WarriorSnapshot struct {
+	State  WarriorState
+	Queue  []Address
+	PSpace []Address
+}
+
+func (s *reportSim) Snapshot() *Snapshot {
+	snapshot := &Snapshot{
+		Mem:          make([]Instruction, len(s.mem)),
+		CycleCount:   int(s.cycleCount),
+		WarriorIndex: s.warriorIndex,
+		FirstWarrior: s.firstWarrior,
+		InCycle:      s.inCycle,
+		Warriors:     make([]WarriorSnapshot, len(s.warriors)),
+	}
+	copy(snapshot.Mem, s.mem)
+
+	for i, w := range s.warriors {
+		pspace := make([]Address, len(w.pspace))
+		copy(pspace, w.pspace)
+		snapshot.Warriors[i] = WarriorSnapshot{
+			State:  w.state,
+			Queue:  w.Queue(),
+			PSpace: pspace,
+		}
+	}
+
+	return snapshot
+}
+
+func (s *reportSim) Restore(snapshot *Snapshot) error {
+	err := s.validateSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	copy(s.mem, snapshot.Mem)
+	s.cycleCount = Address(snapshot.CycleCount)
+	s.warriorIndex = snapshot.WarriorIndex
+	s.firstWarrior = snapshot.FirstWarrior
+	s.inCycle = snapshot.InCycle
+	s.skipCycleBreak = false
+	s.skipExecBreak = false
+	s.warriorLivingCount = 0
+
+	for i, ws := range snapshot.Warriors {
+		w := s.warriors[i]
+		w.state = ws.State
+		if w.state == WarriorAlive {
+			s.warriorLivingCount++
+		}
+		w.pq = newProcessQueue(s.maxProcs)
+		for _, pc := range ws.Queue {
+			w.pq.Push(pc)
+		}
+		copy(w.pspace, ws.PSpace)
+	}
+
+	return nil
+}
+
+// validateSnapshot returns an error if snapshot does not match the
+// configuration and warriors of s
+func (s *reportSim) validateSnapshot(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("nil snapshot")
+	}
+	if Address(len(snapshot.Mem)) != s.m {
+		return fmt.Errorf("snapshot core size %d does not match %d", len(snapshot.Mem), s.m)
+	}
+	for i, inst := range snapshot.Mem {
+		if inst.A >= s.m || inst.B >= s.m {
+			return fmt.Errorf("snapshot address %d: field out of range", i)
+		}
+	}
+	if snapshot.CycleCount < 0 || Address(snapshot.CycleCount) > s.maxCycles {
+		return fmt.Errorf("snapshot cycle count out of range")
+	}
+	if len(snapshot.Warriors) != s.warriorCount {
+		return fmt.Errorf("snapshot has %d warriors, expected %d", len(snapshot.Warriors), s.warriorCount)
+	}
+	if snapshot.WarriorIndex < 0 || (s.warriorCount > 0 && snapshot.WarriorIndex >= s.warriorCount) {
+		return fmt.Errorf("snapshot warrior index out of range")
+	}
+	if snapshot.FirstWarrior < 0 || (s.warriorCount > 0 && snapshot.FirstWarrior >= s.warriorCount) {
+		return fmt.Errorf("snapshot first warrior out of range")
+	}
+
+	for i, ws := range snapshot.Warriors {
+		if ws.State > WarriorDead {
+			return fmt.Errorf("warrior %d: invalid state", i)
+		}
+		if Address(len(ws.Queue)) > s.maxProcs {
+			return fmt.Errorf("warrior %d: queue exceeds max processes", i)
+		}
+		for _, pc := range ws.Queue {
+			if pc >= s.m {
+				return fmt.Errorf("warrior %d: queue address out of range", i)
+			}
+		}
+		if Address(len(ws.PSpace)) != s.pspaceSize {
+			return fmt.Errorf("warrior %d: pspace size %d does not match %d", i, len(ws.PSpace), s.pspaceSize)
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the snapshot as a sequence of varints
+func (snapshot *Snapshot) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, len(snapshot.Mem)*8)
+	buf = append(buf, snapshotMagic...)
+	buf = append(buf, snapshotVersion)
+
+	buf = binary.AppendUvarint(buf, uint64(len(snapshot.Mem)))
+	for _, inst := range snapshot.Mem {
+		buf = appendInstruction(buf, inst)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(snapshot.CycleCount))
+	buf = binary.AppendUvarint(buf, uint64(snapshot.WarriorIndex))
+	buf = binary.AppendUvarint(buf, uint64(snapshot.FirstWarrior))
+	if snapshot.InCycle {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(snapshot.Warriors)))
+	for _, ws := range snapshot.Warriors {
+		buf = append(buf, byte(ws.State))
+		buf = appendAddresses(buf, ws.Queue)
+		buf = appendAddresses(buf, ws.PSpace)
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary
+func (snapshot *Snapshot) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	header := make([]byte, len(snapshotMagic)+1)
+	_, err := io.ReadFull(r, header)
+	if err != nil || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return fmt.Errorf("invalid snapshot header")
+	}
+	if header[len(snapshotMagic)] != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", header[len(snapshotMagic)])
+	}
+
+	decoded := Snapshot{}
+
+	memSize, err := readCount(r)
+	if err != nil {
+		return err
+	}
+	decoded.Mem = make([]Instruction, memSize)
+	for i := range decoded.Mem {
+		decoded.Mem[i], err = readInstruction(r)
+		if err != nil {
+			return fmt.Errorf("reading instruction %d: %s", i, err)
+		}
+	}
+
+	for _, field := range []*int{&decoded.CycleCount, &decoded.WarriorIndex, &decoded.FirstWarrior} {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if v > math.MaxInt32 {
+			return fmt.Errorf("invalid counter %d", v)
+		}
+		*field = int(v)
+	}
+
+	inCycle, err := r.ReadByte()
+	if err != nil || inCycle > 1 {
+		return fmt.Errorf("invalid cycle state")
+	}
+	decoded.InCycle = inCycle == 1
+
+	warriorCount, err := readCount(r)
+	if err != nil {
+		return err
+	}
+	decoded.Warriors = make([]WarriorSnapshot, warriorCount)
+	for i := range decoded.Warriors {
+		state, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("reading warrior %d: %s", i, err)
+		}
+		queue, err := readAddresses(r)
+		if err != nil {
+			return fmt.Errorf("reading warrior %d: %s", i, err)
+		}
+		pspace, err := readAddresses(r)
+		if err != nil {
+			return fmt.Errorf("reading warrior %d: %s", i, err)
+		}
+		decoded.Warriors[i] = WarriorSnapshot{
+			State:  WarriorState(state),
+			Queue:  queue,
+			PSpace: pspace,
+		}
+	}
+
+	if r.Len() != 0 {
+		return fmt.Errorf("unexpected data after snapshot")
+	}
+
+	*snapshot = decoded
+	return nil
+}
+
+// appendInstruction encodes inst as its op and modes in 4 bytes followed
+// by the A and B fields as uvarints
+func appendInstruction(buf []byte, inst Instruction) []byte {
+	buf = append(buf, byte(inst.Op), byte(inst.OpMode), byte(inst.AMode), byte(inst.BMode))
+	buf = binary.AppendUvarint(buf, uint64(inst.A))
+	return binary.AppendUvarint(buf, uint64(inst.B))
+}
+
+func readInstruction(r *bytes.Reader) (Instruction, error) {
+	var fields [4]byte
+	_, err := io.ReadFull(r, fields[:])
+	if err != nil {
+		return Instruction{}, err
+	}
+	a, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Instruction{}, err
+	}
+	b, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Instruction{}, err
+	}
+	return Instruction{
+		Op:     OpCode(fields[0]),
+		OpMode: OpMode(fields[1]),
+		AMode:  AddressMode(fields[2]),
+		A:      Address(a),
+		BMode:  AddressMode(fields[3]),
+		B:      Address(b),
+	}, nil
+}
+
+func appendAddresses(buf []byte, values []Address) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(values)))
+	for _, v := range values {
+		buf = binary.AppendUvarint(buf, uint64(v))
+	}
+	return buf
+}
+
+func readAddresses(r *bytes.Reader) ([]Address, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]Address, n)
+	for i := range values {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = Address(v)
+	}
+	return values, nil
+}
+
+// readCount reads a uvarint used as a length, making sure it is not larger
+// than the data could hold
+func readCount(r *bytes.Reader) (int, error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint64(r.Size()) {
+		return 0, fmt.Errorf("invalid count %d", v)
+	}
+	return int(v), nil
+}
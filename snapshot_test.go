@@ -0,0 +1,126 @@
+package gmars
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotSim(t *testing.T) Simulator {
+	config := ConfigNOP94
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	for i, name := range []string{"warriors/94/bombspiral.red", "warriors/94/paperhaze.red"} {
+		in, err := os.Open(name)
+		require.NoError(t, err)
+		defer in.Close()
+		data, err := CompileWarrior(in, config)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&data)
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(i, Address(i*4000)))
+	}
+	return sim
+}
+
+func coreDump(sim Simulator) []Instruction {
+	mem := make([]Instruction, sim.CoreSize())
+	for i := range mem {
+		mem[i] = sim.GetMem(Address(i))
+	}
+	return mem
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	sim := newSnapshotSim(t)
+	for i := 0; i < 100; i++ {
+		sim.RunCycle()
+	}
+
+	snapshot := sim.Snapshot()
+	assert.Equal(t, 100, snapshot.CycleCount)
+	assert.Equal(t, 2, sim.WarriorLivingCount())
+
+	result, _ := sim.Run()
+	cycles := sim.CycleCount()
+	mem := coreDump(sim)
+	queues := [][]Address{sim.GetWarrior(0).Queue(), sim.GetWarrior(1).Queue()}
+
+	// restoring in the same simulator replays the same battle
+	require.NoError(t, sim.Restore(snapshot))
+	assert.Equal(t, 100, sim.CycleCount())
+	rerun, _ := sim.Run()
+	assert.Equal(t, result, rerun)
+	assert.Equal(t, cycles, sim.CycleCount())
+	assert.Equal(t, mem, coreDump(sim))
+	assert.Equal(t, queues, [][]Address{sim.GetWarrior(0).Queue(), sim.GetWarrior(1).Queue()})
+
+	// restoring in a new simulator forks the battle
+	fork := newSnapshotSim(t)
+	require.NoError(t, fork.Restore(snapshot))
+	forked, _ := fork.Run()
+	assert.Equal(t, result, forked)
+	assert.Equal(t, cycles, fork.CycleCount())
+	assert.Equal(t, mem, coreDump(fork))
+}
+
+func TestSnapshotIsCopy(t *testing.T) {
+	sim := newSnapshotSim(t)
+	snapshot := sim.Snapshot()
+	before := coreDump(sim)
+
+	for i := 0; i < 100; i++ {
+		sim.RunCycle()
+	}
+	assert.Equal(t, before, snapshot.Mem)
+	assert.Equal(t, 0, snapshot.CycleCount)
+}
+
+func TestSnapshotBinary(t *testing.T) {
+	sim := newSnapshotSim(t)
+	for i := 0; i < 250; i++ {
+		sim.RunCycle()
+	}
+	snapshot := sim.Snapshot()
+
+	data, err := snapshot.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := &Snapshot{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, snapshot, decoded)
+
+	require.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+	require.Error(t, decoded.UnmarshalBinary(append(data, 0)))
+	require.Error(t, decoded.UnmarshalBinary([]byte("XXXX\x01")))
+
+	// snapshots from before InCycle was added are rejected
+	old := append([]byte{}, data...)
+	old[len(snapshotMagic)] = 1
+	require.ErrorContains(t, decoded.UnmarshalBinary(old), "unsupported snapshot version 1")
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	sim := newSnapshotSim(t)
+
+	require.Error(t, sim.Restore(nil))
+
+	other, err := NewSimulator(ConfigNopTiny)
+	require.NoError(t, err)
+	require.Error(t, other.Restore(sim.Snapshot()))
+
+	snapshot := sim.Snapshot()
+	snapshot.Warriors = snapshot.Warriors[:1]
+	require.Error(t, sim.Restore(snapshot))
+
+	snapshot = sim.Snapshot()
+	snapshot.Warriors[0].Queue = []Address{sim.CoreSize()}
+	require.Error(t, sim.Restore(snapshot))
+
+	snapshot = sim.Snapshot()
+	snapshot.Mem[0].A = sim.CoreSize()
+	require.Error(t, sim.Restore(snapshot))
+}
@@ -12,18 +12,25 @@ const (
 	CoreTerminated
 )
 
+// RecorderSource provides the core size and warriors to a StateRecorder. It
+// is implemented by every Simulator and by Replay.
+type RecorderSource interface {
+	CoreSize() Address
+	GetWarrior(wi int) Warrior
+}
+
 // StateRecorder implements a Reporter which records the most recent operation
 // performed each core address and the warrior index associated. The initial
 // state of each address is CoreEmpty with a warrior index of -1.
 type StateRecorder struct {
-	sim         ReportingSimulator
+	sim         RecorderSource
 	coresize    Address
 	color       []int
 	state       []CoreState
 	recordReads bool
 }
 
-func NewStateRecorder(sim ReportingSimulator) *StateRecorder {
+func NewStateRecorder(sim RecorderSource) *StateRecorder {
 	coresize := sim.CoreSize()
 
 	color := make([]int, coresize)
@@ -45,10 +52,22 @@ func (r *StateRecorder) GetMemState(a Address) (CoreState, int) {
 	return r.state[a], r.color[a]
 }
 
+// SetRecordRead enables recording reads. It must be called before the
+// recorder is added to a simulator, since WarriorRead reports are filtered
+// out otherwise.
 func (r *StateRecorder) SetRecordRead(val bool) {
 	r.recordReads = val
 }
 
+// ReportMask returns the report types used by the recorder
+func (r *StateRecorder) ReportMask() ReportMask {
+	mask := NewReportMask(SimReset, WarriorSpawn, WarriorTaskTerminate, WarriorTaskPop, WarriorWrite, WarriorIncrement, WarriorDecrement)
+	if r.recordReads {
+		mask |= NewReportMask(WarriorRead)
+	}
+	return mask
+}
+
 func (r *StateRecorder) reset() {
 	for i := Address(0); i < r.coresize; i++ {
 		r.state[i] = CoreEmpty
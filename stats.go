@@ -0,0 +1,215 @@
+package gmars
+
+// WarriorStats holds the execution statistics of a warrior in one round
+type WarriorStats struct {
+	// Executed counts the instructions executed by opcode
+	Executed map[OpCode]int
+
+	// OpponentWrites counts the writes, increments and decrements made to
+	// addresses where another warrior was loaded, and OtherWrites counts
+	// the rest
+	OpponentWrites int
+	OtherWrites    int
+
+	// Turns counts the cycles the warrior executed an instruction in, and
+	// ProcessTotal is the sum of the process count at the start of each turn
+	Turns         int
+	ProcessTotal  int
+	PeakProcesses int
+
+	// DeathCycle is the cycle the warrior died in, or -1 if it survived
+	DeathCycle int
+
+	// DATTerminations counts the processes ended by executing DAT
+	DATTerminations int
+}
+
+// AverageProcesses returns the average process count over the turns of the
+// warrior
+func (s WarriorStats) AverageProcesses() float64 {
+	if s.Turns == 0 {
+		return 0
+	}
+	return float64(s.ProcessTotal) / float64(s.Turns)
+}
+
+// StatsSummary holds the statistics of a warrior summed over many rounds
+type StatsSummary struct {
+	Rounds          int
+	Executed        map[OpCode]int
+	OpponentWrites  int
+	OtherWrites     int
+	Turns           int
+	ProcessTotal    int
+	PeakProcesses   int
+	Deaths          int
+	DeathCycleTotal int
+	DATTerminations int
+}
+
+// AverageProcesses returns the average process count over every turn
+func (s StatsSummary) AverageProcesses() float64 {
+	if s.Turns == 0 {
+		return 0
+	}
+	return float64(s.ProcessTotal) / float64(s.Turns)
+}
+
+// AverageDeathCycle returns the average cycle of death in the rounds the
+// warrior died
+func (s StatsSummary) AverageDeathCycle() float64 {
+	if s.Deaths == 0 {
+		return 0
+	}
+	return float64(s.DeathCycleTotal) / float64(s.Deaths)
+}
+
+// SummarizeStats sums the statistics of each warrior over rounds
+func SummarizeStats(rounds [][]WarriorStats) []StatsSummary {
+	summaries := make([]StatsSummary, 0)
+	for _, round := range rounds {
+		for wi, stats := range round {
+			if wi >= len(summaries) {
+				summaries = append(summaries, StatsSummary{Executed: make(map[OpCode]int)})
+			}
+			summary := &summaries[wi]
+			summary.Rounds++
+			for op, count := range stats.Executed {
+				summary.Executed[op] += count
+			}
+			summary.OpponentWrites += stats.OpponentWrites
+			summary.OtherWrites += stats.OtherWrites
+			summary.Turns += stats.Turns
+			summary.ProcessTotal += stats.ProcessTotal
+			if stats.PeakProcesses > summary.PeakProcesses {
+				summary.PeakProcesses = stats.PeakProcesses
+			}
+			if stats.DeathCycle >= 0 {
+				summary.Deaths++
+				summary.DeathCycleTotal += stats.DeathCycle
+			}
+			summary.DATTerminations += stats.DATTerminations
+		}
+	}
+	return summaries
+}
+
+// codeArea is the range of addresses a warrior was loaded into
+type codeArea struct {
+	start  Address
+	length Address
+}
+
+// StatsReporter implements a Reporter which collects the WarriorStats of
+// each warrior in every round. A round starts when the warriors are spawned
+// and ends when the simulator is reset.
+type StatsReporter struct {
+	sim     RecorderSource
+	rounds  [][]WarriorStats
+	current []WarriorStats
+	areas   []codeArea
+	lastOp  []OpCode
+}
+
+// NewStatsReporter creates a StatsReporter for sim
+func NewStatsReporter(sim RecorderSource) *StatsReporter {
+	return &StatsReporter{sim: sim}
+}
+
+// ReportMask returns the report types used by the reporter
+func (r *StatsReporter) ReportMask() ReportMask {
+	return NewReportMask(SimReset, WarriorSpawn, WarriorTaskPop, WarriorTaskTerminate, WarriorTerminate, WarriorWrite, WarriorIncrement, WarriorDecrement)
+}
+
+// Rounds returns the stats of each round, including the current round
+func (r *StatsReporter) Rounds() [][]WarriorStats {
+	rounds := r.rounds
+	if r.current != nil {
+		rounds = append(rounds[:len(rounds):len(rounds)], r.current)
+	}
+	return rounds
+}
+
+// Summary returns the stats of each warrior summed over every round
+func (r *StatsReporter) Summary() []StatsSummary {
+	return SummarizeStats(r.Rounds())
+}
+
+func (r *StatsReporter) Report(report Report) {
+	if report.Type == SimReset {
+		if r.current != nil {
+			r.rounds = append(r.rounds, r.current)
+			r.current = nil
+		}
+		return
+	}
+
+	wi := report.WarriorIndex
+	if report.Type == WarriorSpawn {
+		r.startRound(wi)
+		w := r.sim.GetWarrior(wi)
+		r.areas[wi] = codeArea{start: report.Address, length: Address(w.Length())}
+		return
+	}
+	if r.current == nil || wi >= len(r.current) {
+		return
+	}
+	stats := &r.current[wi]
+
+	switch report.Type {
+	case WarriorTaskPop:
+		stats.Executed[report.Instruction.Op]++
+		r.lastOp[wi] = report.Instruction.Op
+		processes := report.QueueLen + 1
+		stats.Turns++
+		stats.ProcessTotal += processes
+		if processes > stats.PeakProcesses {
+			stats.PeakProcesses = processes
+		}
+	case WarriorTaskTerminate:
+		if r.lastOp[wi] == DAT {
+			stats.DATTerminations++
+		}
+	case WarriorTerminate:
+		stats.DeathCycle = report.Cycle
+	case WarriorWrite, WarriorIncrement, WarriorDecrement:
+		if r.inOpponentArea(wi, report.Address) {
+			stats.OpponentWrites++
+		} else {
+			stats.OtherWrites++
+		}
+	}
+}
+
+// startRound makes sure there are stats for warrior wi in the current round
+func (r *StatsReporter) startRound(wi int) {
+	if r.current == nil {
+		r.current = make([]WarriorStats, 0)
+	}
+	for len(r.current) <= wi {
+		r.current = append(r.current, WarriorStats{
+			Executed:   make(map[OpCode]int),
+			DeathCycle: -1,
+		})
+	}
+	for len(r.areas) <= wi {
+		r.areas = append(r.areas, codeArea{})
+		r.lastOp = append(r.lastOp, DAT)
+	}
+}
+
+// inOpponentArea returns true if a is in the code area of a warrior other
+// than wi in the current round
+func (r *StatsReporter) inOpponentArea(wi int, a Address) bool {
+	coresize := r.sim.CoreSize()
+	for i := range r.current {
+		if i == wi {
+			continue
+		}
+		area := r.areas[i]
+		if (a+coresize-area.start)%coresize < area.length {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package gmars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runStatsTest runs a round for each entry of cycles with a StatsReporter
+// attached
+func runStatsTest(t *testing.T, code []string, offsets []Address, cycles []int) *StatsReporter {
+	var stats *StatsReporter
+	runReporterTest(t, code, offsets, cycles, func(sim ReportingSimulator) {
+		stats = NewStatsReporter(sim)
+		sim.AddReporter(stats)
+	})
+	return stats
+}
+
+func TestStatsReporterWrites(t *testing.T) {
+	stats := runStatsTest(t, []string{"mov.i $0, $1\n", "jmp $0\n"}, []Address{0, 3}, []int{4})
+
+	rounds := stats.Rounds()
+	require.Len(t, rounds, 1)
+	require.Len(t, rounds[0], 2)
+
+	imp := rounds[0][0]
+	assert.Equal(t, map[OpCode]int{MOV: 4}, imp.Executed)
+	assert.Equal(t, 1, imp.OpponentWrites)
+	assert.Equal(t, 3, imp.OtherWrites)
+	assert.Equal(t, -1, imp.DeathCycle)
+
+	// the jmp is overwritten by the imp in cycle 2 and runs as an imp
+	jmp := rounds[0][1]
+	assert.Equal(t, map[OpCode]int{JMP: 2, MOV: 2}, jmp.Executed)
+	assert.Equal(t, 0, jmp.OpponentWrites)
+	assert.Equal(t, 2, jmp.OtherWrites)
+}
+
+func TestStatsReporterProcesses(t *testing.T) {
+	stats := runStatsTest(t, []string{"spl $0\njmp $-1\n", "dat #0, #0\n"}, []Address{0, 100}, []int{4, 4})
+
+	rounds := stats.Rounds()
+	require.Len(t, rounds, 2)
+
+	paper := rounds[0][0]
+	assert.Equal(t, map[OpCode]int{SPL: 3, JMP: 1}, paper.Executed)
+	assert.Equal(t, 4, paper.Turns)
+	assert.Equal(t, 8, paper.ProcessTotal)
+	assert.Equal(t, 3, paper.PeakProcesses)
+	assert.Equal(t, 2.0, paper.AverageProcesses())
+	assert.Equal(t, -1, paper.DeathCycle)
+
+	dat := rounds[0][1]
+	assert.Equal(t, map[OpCode]int{DAT: 1}, dat.Executed)
+	assert.Equal(t, 1, dat.DATTerminations)
+	assert.Equal(t, 0, dat.DeathCycle)
+
+	summary := stats.Summary()
+	require.Len(t, summary, 2)
+	assert.Equal(t, 2, summary[0].Rounds)
+	assert.Equal(t, 0, summary[0].Deaths)
+	assert.Equal(t, 8, summary[0].Turns)
+	assert.Equal(t, 3, summary[0].PeakProcesses)
+	assert.Equal(t, map[OpCode]int{SPL: 6, JMP: 2}, summary[0].Executed)
+	assert.Equal(t, 2, summary[1].Deaths)
+	assert.Equal(t, 2, summary[1].DATTerminations)
+	assert.Equal(t, 0.0, summary[1].AverageDeathCycle())
+}
+
+func TestSummarizeStats(t *testing.T) {
+	rounds := [][]WarriorStats{
+		{{Executed: map[OpCode]int{MOV: 2}, Turns: 2, ProcessTotal: 4, PeakProcesses: 3, DeathCycle: 10}},
+		{{Executed: map[OpCode]int{MOV: 1, ADD: 1}, Turns: 2, ProcessTotal: 2, PeakProcesses: 1, DeathCycle: 20}},
+		{{Executed: map[OpCode]int{}, DeathCycle: -1}},
+	}
+	summary := SummarizeStats(rounds)
+	require.Len(t, summary, 1)
+	assert.Equal(t, 3, summary[0].Rounds)
+	assert.Equal(t, map[OpCode]int{MOV: 3, ADD: 1}, summary[0].Executed)
+	assert.Equal(t, 3, summary[0].PeakProcesses)
+	assert.Equal(t, 1.5, summary[0].AverageProcesses())
+	assert.Equal(t, 2, summary[0].Deaths)
+	assert.Equal(t, 15.0, summary[0].AverageDeathCycle())
+}
@@ -17,6 +17,7 @@ type symbolScanner struct {
 	valBuf    []token
 	labelBuf  []string
 	forSeen   bool
+	forDepth  int
 	err       error
 
 	symbols map[string][]token
@@ -100,11 +101,20 @@ func scanLabels(p *symbolScanner) scanStateFn {
 			opLower := strings.ToLower(p.nextToken.val)
 			switch opLower {
 			case "equ":
+				if p.forDepth > 0 {
+					return scanConsumeLine
+				}
 				p.valBuf = make([]token, 0)
 				return p.consume(scanEquValue)
 			case "for":
 				p.forSeen = true
-				return nil
+				p.forDepth++
+				return scanConsumeLine
+			case "rof":
+				if p.forDepth > 0 {
+					p.forDepth--
+				}
+				return scanConsumeLine
 			case "end":
 				return nil
 			default:
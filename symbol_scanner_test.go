@@ -54,6 +54,13 @@ func TestSymbolScanner(t *testing.T) {
 				"test": {{tokNumber, "2"}},
 			},
 		},
+		{
+			// scanning continues after nested loops
+			input: "for 1\nfor 1\nq equ 1\nrof\nq equ 2\nrof\nr equ 3\n",
+			output: map[string][]token{
+				"r": {{tokNumber, "3"}},
+			},
+		},
 		{
 			input:  "for 1\nend\nrof\n ~",
 			output: map[string][]token{},
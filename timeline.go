@@ -0,0 +1,221 @@
+package gmars
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TimelineSample holds the process count of each warrior at the start of a
+// cycle. Dead warriors have no processes.
+type TimelineSample struct {
+	Cycle     int   `json:"cycle"`
+	Processes []int `json:"processes"`
+}
+
+// TimelineReporter implements a Reporter which samples the process count of
+// each warrior every interval cycles, starting with cycle 0. A round starts
+// when the warriors are spawned and ends when the simulator is reset.
+type TimelineReporter struct {
+	sim      RecorderSource
+	interval int
+	warriors int
+	rounds   [][]TimelineSample
+	current  []TimelineSample
+}
+
+// NewTimelineReporter creates a TimelineReporter for sim which samples every
+// interval cycles
+func NewTimelineReporter(sim RecorderSource, interval int) (*TimelineReporter, error) {
+	if interval < 1 {
+		return nil, fmt.Errorf("invalid sample interval %d", interval)
+	}
+	return &TimelineReporter{sim: sim, interval: interval}, nil
+}
+
+// ReportMask returns the report types used by the reporter
+func (r *TimelineReporter) ReportMask() ReportMask {
+	return NewReportMask(SimReset, WarriorSpawn, CycleStart)
+}
+
+// Interval returns the number of cycles between samples
+func (r *TimelineReporter) Interval() int {
+	return r.interval
+}
+
+// Rounds returns the samples of each round, including the current round
+func (r *TimelineReporter) Rounds() [][]TimelineSample {
+	rounds := r.rounds
+	if r.current != nil {
+		rounds = append(rounds[:len(rounds):len(rounds)], r.current)
+	}
+	return rounds
+}
+
+func (r *TimelineReporter) Report(report Report) {
+	switch report.Type {
+	case SimReset:
+		if r.current != nil {
+			r.rounds = append(r.rounds, r.current)
+			r.current = nil
+		}
+	case WarriorSpawn:
+		if r.current == nil {
+			r.current = make([]TimelineSample, 0)
+		}
+		if report.WarriorIndex >= r.warriors {
+			r.warriors = report.WarriorIndex + 1
+		}
+	case CycleStart:
+		if r.current == nil || report.Cycle%r.interval != 0 {
+			return
+		}
+		sample := TimelineSample{Cycle: report.Cycle, Processes: make([]int, r.warriors)}
+		for wi := range sample.Processes {
+			w := r.sim.GetWarrior(wi)
+			if w.Alive() {
+				sample.Processes[wi] = int(w.ThreadCount())
+			}
+		}
+		r.current = append(r.current, sample)
+	}
+}
+
+// TimelinePoint holds the process counts of each warrior at one cycle,
+// aggregated over every round. Rounds which ended before the cycle count as 0
+// processes for every warrior, and Rounds is the number still running.
+type TimelinePoint struct {
+	Cycle  int       `json:"cycle"`
+	Rounds int       `json:"rounds"`
+	Mean   []float64 `json:"mean"`
+	Min    []int     `json:"min"`
+	Max    []int     `json:"max"`
+}
+
+// Timeline holds the samples of every round of a battle and their aggregate
+type Timeline struct {
+	Interval  int                `json:"interval"`
+	Rounds    [][]TimelineSample `json:"rounds"`
+	Aggregate []TimelinePoint    `json:"aggregate"`
+}
+
+// NewTimeline aggregates rounds sampled every interval cycles
+func NewTimeline(interval int, rounds [][]TimelineSample) Timeline {
+	aggregate := make([]TimelinePoint, 0)
+	for _, round := range rounds {
+		// every round is sampled at the same cycles, so the samples of
+		// each round line up by index
+		for i, sample := range round {
+			if i >= len(aggregate) {
+				aggregate = append(aggregate, TimelinePoint{
+					Cycle: sample.Cycle,
+					Mean:  make([]float64, len(sample.Processes)),
+					Min:   make([]int, len(sample.Processes)),
+					Max:   make([]int, len(sample.Processes)),
+				})
+			}
+			point := &aggregate[i]
+			for wi, processes := range sample.Processes {
+				if wi >= len(point.Mean) {
+					break
+				}
+				point.Mean[wi] += float64(processes)
+				if point.Rounds == 0 || processes < point.Min[wi] {
+					point.Min[wi] = processes
+				}
+				if processes > point.Max[wi] {
+					point.Max[wi] = processes
+				}
+			}
+			point.Rounds++
+		}
+	}
+	for i := range aggregate {
+		point := &aggregate[i]
+		for wi := range point.Mean {
+			if point.Rounds < len(rounds) {
+				point.Min[wi] = 0
+			}
+			point.Mean[wi] /= float64(len(rounds))
+		}
+	}
+
+	return Timeline{
+		Interval:  interval,
+		Rounds:    rounds,
+		Aggregate: aggregate,
+	}
+}
+
+// warriorCount returns the number of warriors sampled in the timeline
+func (t Timeline) warriorCount() int {
+	count := 0
+	for _, point := range t.Aggregate {
+		if len(point.Mean) > count {
+			count = len(point.Mean)
+		}
+	}
+	return count
+}
+
+// WriteCSV writes every sample to w as CSV, with a row for each sample
+// holding the round, the cycle and the process count of each warrior
+func (t Timeline) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	count := t.warriorCount()
+
+	header := []string{"round", "cycle"}
+	for wi := 0; wi < count; wi++ {
+		header = append(header, fmt.Sprintf("w%d", wi))
+	}
+	out.Write(header)
+
+	for round, samples := range t.Rounds {
+		for _, sample := range samples {
+			row := []string{strconv.Itoa(round), strconv.Itoa(sample.Cycle)}
+			for _, processes := range sample.Processes {
+				row = append(row, strconv.Itoa(processes))
+			}
+			out.Write(row)
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// WriteAggregateCSV writes the aggregate to w as CSV, with a row for each
+// cycle holding the number of rounds and the mean, minimum and maximum
+// process count of each warrior
+func (t Timeline) WriteAggregateCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	count := t.warriorCount()
+
+	header := []string{"cycle", "rounds"}
+	for wi := 0; wi < count; wi++ {
+		header = append(header, fmt.Sprintf("w%d_mean", wi), fmt.Sprintf("w%d_min", wi), fmt.Sprintf("w%d_max", wi))
+	}
+	out.Write(header)
+
+	for _, point := range t.Aggregate {
+		row := []string{strconv.Itoa(point.Cycle), strconv.Itoa(point.Rounds)}
+		for wi := range point.Mean {
+			row = append(row,
+				strconv.FormatFloat(point.Mean[wi], 'f', -1, 64),
+				strconv.Itoa(point.Min[wi]),
+				strconv.Itoa(point.Max[wi]),
+			)
+		}
+		out.Write(row)
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// WriteJSON writes the timeline to w as a JSON object
+func (t Timeline) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
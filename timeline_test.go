@@ -0,0 +1,91 @@
+package gmars
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTimelineTest runs a round for each entry of cycles with a
+// TimelineReporter attached
+func runTimelineTest(t *testing.T, code []string, offsets []Address, interval int, cycles []int) *TimelineReporter {
+	var timeline *TimelineReporter
+	runReporterTest(t, code, offsets, cycles, func(sim ReportingSimulator) {
+		var err error
+		timeline, err = NewTimelineReporter(sim, interval)
+		require.NoError(t, err)
+		sim.AddReporter(timeline)
+	})
+	return timeline
+}
+
+func TestTimelineReporter(t *testing.T) {
+	timeline := runTimelineTest(t, []string{"spl $0\njmp $-1\n", "jmp $0\n"}, []Address{0, 100}, 2, []int{6, 3})
+	assert.Equal(t, 2, timeline.Interval())
+
+	rounds := timeline.Rounds()
+	require.Len(t, rounds, 2)
+	assert.Equal(t, []TimelineSample{
+		{Cycle: 0, Processes: []int{1, 1}},
+		{Cycle: 2, Processes: []int{2, 1}},
+		{Cycle: 4, Processes: []int{4, 1}},
+	}, rounds[0])
+	assert.Equal(t, []TimelineSample{
+		{Cycle: 0, Processes: []int{1, 1}},
+		{Cycle: 2, Processes: []int{2, 1}},
+	}, rounds[1])
+
+	aggregate := NewTimeline(timeline.Interval(), rounds).Aggregate
+	assert.Equal(t, []TimelinePoint{
+		{Cycle: 0, Rounds: 2, Mean: []float64{1, 1}, Min: []int{1, 1}, Max: []int{1, 1}},
+		{Cycle: 2, Rounds: 2, Mean: []float64{2, 1}, Min: []int{2, 1}, Max: []int{2, 1}},
+		{Cycle: 4, Rounds: 1, Mean: []float64{2, 0.5}, Min: []int{0, 0}, Max: []int{4, 1}},
+	}, aggregate)
+}
+
+func TestTimelineReporterDeath(t *testing.T) {
+	timeline := runTimelineTest(t, []string{"jmp $0\n", "dat $0, $0\n", "jmp $0\n"}, []Address{0, 100, 200}, 1, []int{3})
+
+	rounds := timeline.Rounds()
+	require.Len(t, rounds, 1)
+	assert.Equal(t, []TimelineSample{
+		{Cycle: 0, Processes: []int{1, 1, 1}},
+		{Cycle: 1, Processes: []int{1, 0, 1}},
+		{Cycle: 2, Processes: []int{1, 0, 1}},
+	}, rounds[0])
+}
+
+func TestTimelineReporterInterval(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94)
+	require.NoError(t, err)
+	_, err = NewTimelineReporter(sim, 0)
+	require.Error(t, err)
+}
+
+func TestTimelineAggregate(t *testing.T) {
+	timeline := NewTimeline(10, [][]TimelineSample{
+		{{Cycle: 0, Processes: []int{1, 1}}, {Cycle: 10, Processes: []int{3, 1}}},
+		{{Cycle: 0, Processes: []int{1, 1}}, {Cycle: 10, Processes: []int{8, 0}}},
+	})
+	assert.Equal(t, []TimelinePoint{
+		{Cycle: 0, Rounds: 2, Mean: []float64{1, 1}, Min: []int{1, 1}, Max: []int{1, 1}},
+		{Cycle: 10, Rounds: 2, Mean: []float64{5.5, 0.5}, Min: []int{3, 0}, Max: []int{8, 1}},
+	}, timeline.Aggregate)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, timeline.WriteCSV(buf))
+	assert.Equal(t, "round,cycle,w0,w1\n0,0,1,1\n0,10,3,1\n1,0,1,1\n1,10,8,0\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, timeline.WriteAggregateCSV(buf))
+	assert.Equal(t, "cycle,rounds,w0_mean,w0_min,w0_max,w1_mean,w1_min,w1_max\n0,2,1,1,1,1,1,1\n10,2,5.5,3,8,0.5,0,1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, timeline.WriteJSON(buf))
+	decoded := Timeline{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, timeline, decoded)
+}
@@ -0,0 +1,150 @@
+package gmars
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TournamentResult holds the totals of a warrior over every round of every
+// battle it played in a Tournament.
+type TournamentResult struct {
+	Name   string
+	Wins   int
+	Losses int
+	Ties   int
+	Score  int
+}
+
+// Tournament plays a round robin tournament where every warrior battles
+// every other warrior for a fixed number of rounds.
+type Tournament struct {
+	config   SimulatorConfig
+	rounds   int
+	workers  int
+	names    []string
+	warriors []WarriorData
+	seed     int64
+}
+
+func NewTournament(config SimulatorConfig, rounds int) (*Tournament, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if rounds < 1 {
+		return nil, fmt.Errorf("invalid round count")
+	}
+	return &Tournament{
+		config: config,
+		rounds: rounds,
+		seed:   NewSeed(),
+	}, nil
+}
+
+// AddWarrior adds a warrior to the tournament under name
+func (t *Tournament) AddWarrior(name string, data WarriorData) {
+	t.names = append(t.names, name)
+	t.warriors = append(t.warriors, data)
+}
+
+// LoadDir compiles every .red file in dir and adds it to the tournament
+// using the file name as the warrior name.
+func (t *Tournament) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".red" {
+			continue
+		}
+
+		in, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		data, err := CompileWarrior(in, t.config)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", entry.Name(), err)
+		}
+
+		t.AddWarrior(entry.Name(), data)
+	}
+
+	return nil
+}
+
+// SetWorkers sets the number of goroutines used to run battles in parallel.
+// By default, one is used for each CPU.
+func (t *Tournament) SetWorkers(workers int) {
+	t.workers = workers
+}
+
+// SetSeed sets the seed used to place warriors. Each battle is placed with
+// its own seed derived from seed and the indexes of the two warriors.
+func (t *Tournament) SetSeed(seed int64) {
+	t.seed = seed
+}
+
+// Seed returns the seed used to place warriors
+func (t *Tournament) Seed() int64 {
+	return t.seed
+}
+
+// WarriorCount returns the number of warriors in the tournament
+func (t *Tournament) WarriorCount() int {
+	return len(t.warriors)
+}
+
+// Run plays every pairing of warriors and returns the results ranked by
+// score, with 3 points for a win and 1 point for a tie.
+func (t *Tournament) Run() ([]TournamentResult, error) {
+	if len(t.warriors) < 2 {
+		return nil, fmt.Errorf("at least two warriors are required")
+	}
+
+	results := make([]TournamentResult, len(t.warriors))
+	for i, name := range t.names {
+		results[i].Name = name
+	}
+
+	pairs := make([][2]int, 0)
+	runners := make([]*BattleRunner, 0)
+	for i := 0; i < len(t.warriors); i++ {
+		for j := i + 1; j < len(t.warriors); j++ {
+			runner, err := NewBattleRunner(t.config, []WarriorData{t.warriors[i], t.warriors[j]})
+			if err != nil {
+				return nil, fmt.Errorf("%s vs %s: %s", t.names[i], t.names[j], err)
+			}
+			runner.SetSeed(mixSeed(mixSeed(t.seed, int64(i)), int64(j)))
+			pairs = append(pairs, [2]int{i, j})
+			runners = append(runners, runner)
+		}
+	}
+
+	battles, err := RunBattles(context.Background(), runners, t.rounds, t.workers)
+	if err != nil {
+		return nil, err
+	}
+
+	for p, pair := range pairs {
+		for k, wi := range pair {
+			results[wi].Wins += battles[p].Wins[k]
+			results[wi].Losses += battles[p].Losses[k]
+			results[wi].Ties += battles[p].Ties[k]
+			results[wi].Score += battles[p].Scores[k]
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+
+	return results, nil
+}
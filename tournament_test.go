@@ -0,0 +1,100 @@
+package gmars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTournament(t *testing.T) {
+	config := ConfigNopNano
+
+	imp := WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	dat := WarriorData{Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+
+	tournament, err := NewTournament(config, 4)
+	require.NoError(t, err)
+	tournament.AddWarrior("dat", dat)
+	tournament.AddWarrior("imp1", imp)
+	tournament.AddWarrior("imp2", imp)
+
+	results, err := tournament.Run()
+	require.NoError(t, err)
+	assert.Equal(t, []TournamentResult{
+		{Name: "imp1", Wins: 4, Losses: 0, Ties: 4, Score: 16},
+		{Name: "imp2", Wins: 4, Losses: 0, Ties: 4, Score: 16},
+		{Name: "dat", Wins: 0, Losses: 8, Ties: 0, Score: 0},
+	}, results)
+}
+
+func TestTournamentLoadDir(t *testing.T) {
+	tournament, err := NewTournament(ConfigNOP94, 1)
+	require.NoError(t, err)
+
+	err = tournament.LoadDir("warriors/94")
+	require.NoError(t, err)
+	assert.Equal(t, 5, tournament.WarriorCount())
+
+	results, err := tournament.Run()
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	assert.ElementsMatch(t, []string{"bombspiral.red", "imp.red", "paperhaze.red", "scaryvampire.red", "simpleshot.red"}, names)
+}
+
+func TestTournamentFirstWarrior(t *testing.T) {
+	config := ConfigNopNano
+
+	// the warrior that moves first dies first and loses, so both warriors
+	// only win rounds if the starting order alternates
+	dat := WarriorData{Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+
+	tournament, err := NewTournament(config, 4)
+	require.NoError(t, err)
+	tournament.AddWarrior("dat1", dat)
+	tournament.AddWarrior("dat2", dat)
+
+	results, err := tournament.Run()
+	require.NoError(t, err)
+	assert.Equal(t, []TournamentResult{
+		{Name: "dat1", Wins: 2, Losses: 2, Ties: 0, Score: 6},
+		{Name: "dat2", Wins: 2, Losses: 2, Ties: 0, Score: 6},
+	}, results)
+}
+
+func TestTournamentInvalid(t *testing.T) {
+	_, err := NewTournament(ConfigNOP94, 0)
+	require.Error(t, err)
+
+	tournament, err := NewTournament(ConfigNOP94, 1)
+	require.NoError(t, err)
+	_, err = tournament.Run()
+	require.Error(t, err)
+
+	err = tournament.LoadDir("does/not/exist")
+	require.Error(t, err)
+}
+
+func TestTournamentSeed(t *testing.T) {
+	var expected []TournamentResult
+	for i := 0; i < 2; i++ {
+		tournament, err := NewTournament(ConfigNOP94, 4)
+		require.NoError(t, err)
+		tournament.SetSeed(99)
+		assert.Equal(t, int64(99), tournament.Seed())
+
+		err = tournament.LoadDir("warriors/94")
+		require.NoError(t, err)
+
+		results, err := tournament.Run()
+		require.NoError(t, err)
+		if i == 0 {
+			expected = results
+		} else {
+			assert.Equal(t, expected, results)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gmars
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// TraceVersion is the version of the trace format written by TraceReporter
+const TraceVersion = 2
+
+// TraceHeader describes the battle at the start of a trace
+type TraceHeader struct {
+	Version  int             `json:"version"`
+	Config   SimulatorConfig `json:"config"`
+	Warriors []WarriorData   `json:"warriors"`
+}
+
+// TraceEvent is a Report as written in a trace. Round counts from 0 and moves
+// to the next round after each SimReset report. The payload fields are
+// omitted for report types that do not set them.
+type TraceEvent struct {
+	Round       int          `json:"round"`
+	Type        ReportType   `json:"type"`
+	Cycle       int          `json:"cycle"`
+	Warrior     int          `json:"warrior"`
+	Address     Address      `json:"address"`
+	Instruction *Instruction `json:"instruction,omitempty"`
+	Before      *Instruction `json:"before,omitempty"`
+	QueueLen    int          `json:"queue_len,omitempty"`
+}
+
+// TraceLine is a single line of a trace, holding either the header or an
+// event
+type TraceLine struct {
+	Header *TraceHeader `json:"header,omitempty"`
+	*TraceEvent
+}
+
+// NewTraceEvent returns the TraceEvent for a report
+func NewTraceEvent(report Report) TraceEvent {
+	event := TraceEvent{
+		Type:    report.Type,
+		Cycle:   report.Cycle,
+		Warrior: report.WarriorIndex,
+		Address: report.Address,
+	}
+	switch report.Type {
+	case WarriorTaskPop:
+		event.Instruction = &report.Instruction
+		event.QueueLen = report.QueueLen
+	case WarriorTaskPush, WarriorProcessLimit:
+		event.QueueLen = report.QueueLen
+	case WarriorRead:
+		event.Instruction = &report.Instruction
+	case WarriorWrite, WarriorIncrement, WarriorDecrement, WarriorPSpaceWrite:
+		event.Instruction = &report.Instruction
+		event.Before = &report.Before
+	}
+	return event
+}
+
+// TraceReporter implements a Reporter which writes every report to a
+// writer in the JSON Lines format.
+//
+// The trace starts with a header line holding a TraceHeader, followed by a
+// line for each report. The start offsets of each round are given by its
+// WarriorSpawn events. Enum values such as opcodes and modes are written as
+// their names.
+//
+// Lines are written through a buffer, and the end of the trace is only
+// written by Flush. A TraceReporter keeps the state of the current round and
+// is not safe for concurrent use, so it must only be added to one Simulator,
+// and not shared by battles run in parallel with RunBattles.
+type TraceReporter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	err   error
+	round int
+}
+
+// NewTraceReporter creates a TraceReporter writing to w for a battle
+// between warriors
+func NewTraceReporter(w io.Writer, config SimulatorConfig, warriors []WarriorData) *TraceReporter {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	// keep address modes such as '<' and '>' readable
+	enc.SetEscapeHTML(false)
+	t := &TraceReporter{
+		w:   bw,
+		enc: enc,
+	}
+	t.encode(TraceLine{Header: &TraceHeader{
+		Version:  TraceVersion,
+		Config:   config,
+		Warriors: warriors,
+	}})
+	return t
+}
+
+func (t *TraceReporter) Report(report Report) {
+	event := NewTraceEvent(report)
+	event.Round = t.round
+	t.encode(TraceLine{TraceEvent: &event})
+	if report.Type == SimReset {
+		t.round++
+	}
+}
+
+// encode writes a line, keeping the first error
+func (t *TraceReporter) encode(line TraceLine) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.enc.Encode(line)
+}
+
+// Flush writes any buffered output and returns the first error
+// encountered while writing the trace
+func (t *TraceReporter) Flush() error {
+	if t.err != nil {
+		return t.err
+	}
+	t.err = t.w.Flush()
+	return t.err
+}
@@ -0,0 +1,110 @@
+package gmars
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceReporter(t *testing.T) {
+	imp := WarriorData{Name: "imp", Code: []Instruction{{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1}}}
+	dat := WarriorData{Name: "dat", Code: []Instruction{{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 0, BMode: IMMEDIATE, B: 0}}}
+	warriors := []WarriorData{imp, dat}
+
+	runner, err := NewBattleRunner(ConfigNopNano, warriors)
+	require.NoError(t, err)
+	runner.SetPlacement(func(round int) (Placement, error) {
+		return Placement{Offsets: []Address{0, Address(10 + round)}}, nil
+	})
+
+	buf := &bytes.Buffer{}
+	trace := NewTraceReporter(buf, ConfigNopNano, warriors)
+	runner.SetReporterFunc(func(sim ReportingSimulator) {
+		sim.AddReporter(trace)
+	})
+	_, err = runner.Run(context.Background(), 2)
+	require.NoError(t, err)
+	require.NoError(t, trace.Flush())
+
+	lines := make([]TraceLine, 0)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line TraceLine
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+
+	// the trace starts with a single header, and each round is two spawns
+	// and then the first cycle where the dat warrior dies
+	require.NotNil(t, lines[0].Header)
+	assert.Equal(t, TraceHeader{
+		Version:  TraceVersion,
+		Config:   ConfigNopNano,
+		Warriors: warriors,
+	}, *lines[0].Header)
+
+	events := make([]TraceEvent, 0)
+	for _, line := range lines[1:] {
+		require.Nil(t, line.Header)
+		require.NotNil(t, line.TraceEvent)
+		events = append(events, *line.TraceEvent)
+	}
+
+	assert.Equal(t, []TraceEvent{
+		{Type: WarriorSpawn, Warrior: 0, Address: 0},
+		{Type: WarriorSpawn, Warrior: 1, Address: 10},
+		{Type: CycleStart},
+		{Type: WarriorTaskPop, Warrior: 0, Address: 0, Instruction: &imp.Code[0]},
+		{Type: WarriorRead, Warrior: 0, Address: 0, Instruction: &imp.Code[0]},
+		{Type: WarriorTaskPush, Warrior: 0, Address: 1, QueueLen: 1},
+		{Type: WarriorWrite, Warrior: 0, Address: 1, Instruction: &imp.Code[0], Before: &Instruction{}},
+		{Type: WarriorTaskPop, Warrior: 1, Address: 10, Instruction: &dat.Code[0]},
+		{Type: WarriorTaskTerminate, Warrior: 1, Address: 10},
+		{Type: WarriorTerminate, Warrior: 1, Address: 10},
+		{Type: SimReset},
+	}, events[:11])
+	assert.Equal(t, TraceEvent{Round: 1, Type: WarriorSpawn, Warrior: 1, Address: 11}, events[12])
+	assert.Equal(t, 1, events[len(events)-1].Round)
+}
+
+func TestTraceEventJSON(t *testing.T) {
+	report := Report{Type: WarriorWrite, Cycle: 3, WarriorIndex: 1, Address: 5}
+	data, err := json.Marshal(NewTraceEvent(report))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"WarriorWrite"`)
+
+	var event TraceEvent
+	require.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, NewTraceEvent(report), event)
+
+	require.Error(t, json.Unmarshal([]byte(`{"type":"Bogus"}`), &event))
+}
+
+func TestTraceHeaderJSON(t *testing.T) {
+	header := TraceHeader{
+		Version: TraceVersion,
+		Config:  ConfigNOP94,
+		Warriors: []WarriorData{{Name: "imp", Code: []Instruction{
+			{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: B_INDIRECT, B: 1},
+		}}},
+	}
+	data, err := json.Marshal(header)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"mode":"ICWS94","core_size":8000`)
+	assert.Contains(t, string(data), `"code":[{"op":"MOV","op_mode":"I","a":0,"a_mode":"$","b":1,"b_mode":"@"}]`)
+
+	var decoded TraceHeader
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, header, decoded)
+
+	_, err = json.Marshal(Instruction{Op: 100})
+	require.Error(t, err)
+	require.Error(t, json.Unmarshal([]byte(`{"op":"XYZ"}`), &Instruction{}))
+	require.Error(t, json.Unmarshal([]byte(`{"mode":"ICWS00"}`), &SimulatorConfig{}))
+}
@@ -11,11 +11,11 @@ const (
 )
 
 type WarriorData struct {
-	Name     string        // Warrior Name
-	Author   string        // Author Name
-	Strategy string        // Strategy including multiple lines
-	Code     []Instruction // Program Instructions
-	Start    int           // Program Entry Point
+	Name     string        `json:"name"`     // Warrior Name
+	Author   string        `json:"author"`   // Author Name
+	Strategy string        `json:"strategy"` // Strategy including multiple lines
+	Code     []Instruction `json:"code"`     // Program Instructions
+	Start    int           `json:"start"`    // Program Entry Point
 }
 
 type Warrior interface {
@@ -24,6 +24,7 @@ type Warrior interface {
 	Author() string
 	Length() int
 	Queue() []Address
+	ThreadCount() Address
 	NextPC() (Address, error)
 	LoadCode() string
 }
@@ -43,12 +44,12 @@ func (w *WarriorData) Copy() *WarriorData {
 
 // warrior is a manifestation WarriorData in a Simulator
 type warrior struct {
-	data  *WarriorData
-	sim   *reportSim
-	index int
-	pq    *processQueue
-	// pspace []Instruction
-	state WarriorState
+	data   *WarriorData
+	sim    *reportSim
+	index  int
+	pq     *processQueue
+	pspace []Address
+	state  WarriorState
 }
 
 // Name returns the Warrior's Name
@@ -75,7 +76,11 @@ func (w *warrior) Alive() bool {
 	return w.state == WarriorAlive
 }
 
+// ThreadCount returns the number of processes in the warrior's queue
 func (w *warrior) ThreadCount() Address {
+	if w.pq == nil {
+		return 0
+	}
 	return w.pq.Len()
 }
 